@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+type AtomFeed struct {
+	Title    AtomText     `xml:"title"`
+	Subtitle AtomText     `xml:"subtitle"`
+	Updated  string       `xml:"updated"`
+	Links    []AtomLink   `xml:"link"`
+	Authors  []AtomPerson `xml:"author"`
+	Entries  []AtomEntry  `xml:"entry"`
+}
+
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      AtomText       `xml:"title"`
+	Links      []AtomLink     `xml:"link"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Summary    AtomText       `xml:"summary"`
+	Content    AtomText       `xml:"content"`
+	Authors    []AtomPerson   `xml:"author"`
+	Categories []AtomCategory `xml:"category"`
+}
+
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+type AtomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type AtomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type AtomText struct { //Atom text construct, either plain text, escaped html or inline xhtml
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t AtomText) String() string { //Returns the text content, keeping inline xhtml markup intact
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+func parseAtom(data []byte) (*parsedFeed, error) { //Decodes an Atom 1.0 document and maps it into the shared parsedFeed format
+	var atom AtomFeed
+	if err := xml.Unmarshal(data, &atom); err != nil {
+		return nil, fmt.Errorf("error decoding atom data: %w", err)
+	}
+
+	var feed parsedFeed
+	feed.Title = atom.Title.String()
+	feed.Link = alternateLink(atom.Links)
+	feed.Description = atom.Subtitle.String()
+	feed.PubDate = strings.TrimSpace(atom.Updated)
+
+	for _, entry := range atom.Entries {
+		item := feedItem{
+			Title:       entry.Title.String(),
+			Link:        alternateLink(entry.Links),
+			Description: entry.Summary.String(),
+			PubDate:     strings.TrimSpace(entry.Published),
+			GUID:        strings.TrimSpace(entry.ID),
+			Content:     entry.Content.String(),
+			Author:      personNames(entry.Authors),
+			Comments:    relLink(entry.Links, "replies"),
+		}
+		if item.Author == "" { //Entries inherit the feed's authors
+			item.Author = personNames(atom.Authors)
+		}
+		for _, category := range entry.Categories {
+			item.Categories = append(item.Categories, category.Term)
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" {
+				item.Enclosures = append(item.Enclosures, feedEnclosure{URL: strings.TrimSpace(link.Href), Length: link.Length, Type: link.Type})
+			}
+		}
+		if item.Description == "" { //Falls back to full content when no summary is given
+			item.Description = entry.Content.String()
+		}
+		if item.PubDate == "" {
+			item.PubDate = strings.TrimSpace(entry.Updated)
+		}
+		if item.Link == "" { //Entry ids are often permalinks when no link is given
+			item.Link = item.GUID
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return &feed, nil
+}
+
+func alternateLink(links []AtomLink) string { //Picks the alternate link from a list of atom links, a missing rel defaults to alternate
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	if len(links) > 0 {
+		return strings.TrimSpace(links[0].Href)
+	}
+	return ""
+}
+
+func relLink(links []AtomLink, rel string) string { //Returns the first link with the given rel
+	for _, link := range links {
+		if link.Rel == rel {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+func personNames(people []AtomPerson) string { //Joins the names of a list of authors
+	var names []string
+	for _, person := range people {
+		if name := strings.TrimSpace(person.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,85 @@
+package main
+
+import "testing"
+
+func TestParseAtom(t *testing.T) {
+	feed, err := parseAtom(readTestdata(t, "feed.atom"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if feed.Link != "https://example.com/" {
+		t.Errorf("channel link = %q, want the alternate link", feed.Link)
+	}
+	if feed.Description != "Notes from an example" {
+		t.Errorf("channel description = %q", feed.Description)
+	}
+	if len(feed.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(feed.Items))
+	}
+
+	tests := []struct {
+		name string
+		got  feedItem
+		want feedItem
+	}{
+		{
+			name: "full entry",
+			got:  feed.Items[0],
+			want: feedItem{
+				Title:       "First post",
+				Link:        "https://example.com/first",
+				Description: "A short summary",
+				PubDate:     "2024-05-01T09:00:00Z",
+				GUID:        "tag:example.com,2024:first",
+				Content:     `<div xmlns="http://www.w3.org/1999/xhtml"><p>Full text</p></div>`,
+				Author:      "Jane Doe",
+				Comments:    "https://example.com/first#comments",
+			},
+		},
+		{
+			name: "entry with fallbacks",
+			got:  feed.Items[1],
+			want: feedItem{
+				Title:       "Second post",
+				Link:        "https://example.com/second",
+				Description: "<p>Only content</p>",
+				PubDate:     "2024-05-03T09:00:00Z",
+				GUID:        "https://example.com/second",
+				Content:     "<p>Only content</p>",
+				Author:      "John Roe",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := []struct {
+				field     string
+				got, want string
+			}{
+				{"title", tt.got.Title, tt.want.Title},
+				{"link", tt.got.Link, tt.want.Link},
+				{"description", tt.got.Description, tt.want.Description},
+				{"pubDate", tt.got.PubDate, tt.want.PubDate},
+				{"guid", tt.got.GUID, tt.want.GUID},
+				{"content", tt.got.Content, tt.want.Content},
+				{"author", tt.got.Author, tt.want.Author},
+				{"comments", tt.got.Comments, tt.want.Comments},
+			}
+			for _, f := range fields {
+				if f.got != f.want {
+					t.Errorf("%s = %q, want %q", f.field, f.got, f.want)
+				}
+			}
+		})
+	}
+
+	first := feed.Items[0]
+	if len(first.Categories) != 1 || first.Categories[0] != "news" {
+		t.Errorf("categories = %v, want [news]", first.Categories)
+	}
+	wantEnclosure := feedEnclosure{URL: "https://example.com/first.mp3", Length: "1024", Type: "audio/mpeg"}
+	if len(first.Enclosures) != 1 || first.Enclosures[0] != wantEnclosure {
+		t.Errorf("enclosures = %+v, want [%+v]", first.Enclosures, wantEnclosure)
+	}
+}
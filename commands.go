@@ -3,10 +3,14 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
-	"strings"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 	"github.com/google/uuid"
 	"github.com/jms-guy/gator/internal/config"
@@ -27,7 +31,7 @@ type commands struct {	//Each command in the list of commands
 	cmds	map[string]func(*state, command) error
 }
 
-func handlerAgg(s *state, cmd command) error {	//Aggregator service
+func handlerAgg(s *state, cmd command) error {	//Aggregator service, takes a time duration (also the default fetch interval) and an optional number of concurrent workers
 	if len(cmd.args) == 0 {
 		return fmt.Errorf("missing time duration")
 	}
@@ -37,45 +41,150 @@ func handlerAgg(s *state, cmd command) error {	//Aggregator service
 	if err != nil {
 		return fmt.Errorf("error parsing duration string: %w", err)
 	}
-	fmt.Printf(" ~~Collecting feeds every %s~~\n", time_between_reqs)
+	if timeBetweenReqs < time.Second {	//Fetch intervals are kept in whole seconds, and a shorter duration would spin the ticker
+		return fmt.Errorf("time duration must be at least 1s")
+	}
+
+	workers := 1
+	if len(cmd.args) > 1 {
+		workers, err = strconv.Atoi(cmd.args[1])
+		if err != nil {
+			return fmt.Errorf("number conversion error: %w", err)
+		}
+		if workers < 1 {
+			return fmt.Errorf("concurrency must be at least 1")
+		}
+	}
+	fmt.Printf(" ~~Collecting feeds every %s with %d worker(s)~~\n", time_between_reqs, workers)
 	ticker := time.NewTicker(timeBetweenReqs)
 	for ; ; <-ticker.C {
-		scrapeFeeds(s)
+		if err := scrapeFeeds(s, workers, timeBetweenReqs); err != nil {
+			fmt.Println(err)
+		}
 	}
 }
 
-func handlerBrowse(s *state, cmd command, user database.User) error {	//Browses posts from feeds followed by user, takes optional limit input
+func handlerBrowse(s *state, cmd command, user database.User) error {	//Browses posts from feeds followed by user, takes optional limit input, and filter/paging flags
+	flags := flag.NewFlagSet("browse", flag.ContinueOnError)
+	unread := flags.Bool("unread", false, "only show posts that have not been read")
+	markRead := flags.Bool("mark-read", false, "mark shown posts as read")
+	var feeds stringList
+	flags.Var(&feeds, "feed", "only show posts from this feed url or name, can be repeated")
+	since := flags.String("since", "", "only show posts published on or after this date")
+	until := flags.String("until", "", "only show posts published on or before this date")
+	keyword := flags.String("keyword", "", "only show posts with this text in the title or description")
+	page := flags.Int("page", 1, "page of results to show")
+	before := flags.String("before", "", "only show posts after this post id in browse order, as printed for the next page")
+	sortOrder := flags.String("sort", "newest", "order of posts: newest, oldest, created or feed")
+	args, err := parseFlags(flags, cmd.args)
+	if err != nil {
+		return err
+	}
+	sinceTime, err := parseDateFlag(*since, false)
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	untilTime, err := parseDateFlag(*until, true)
+	if err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+	var keywordFilter sql.NullString
+	if *keyword != "" {
+		keywordFilter = nullString(likeEscaper.Replace(*keyword))
+	}
+
 	var limit int32
-	if len(cmd.args) == 0 {
+	if len(args) == 0 {
 		limit = 2
 	} else {
-		number, err := strconv.Atoi(cmd.args[0])
+		number, err := strconv.Atoi(args[0])
 		if err != nil {
 			return fmt.Errorf("number conversion error: %w", err)
 		}
 		limit = int32(number)
 	}
+	if limit < 1 {
+		return fmt.Errorf("limit must be at least 1")
+	}
+	if *page < 1 {
+		return fmt.Errorf("page must be at least 1")
+	}
+	if !browseSorts[*sortOrder] {
+		return fmt.Errorf("unknown sort %q, expected newest, oldest, created or feed", *sortOrder)
+	}
+
+	var beforeID uuid.NullUUID
+	if *before != "" {	//Keyset cursor, posts that come after the given one in the chosen sort order
+		cursor, err := resolvePost(s, *before)
+		if err != nil {
+			return err
+		}
+		beforeID = uuid.NullUUID{
+			UUID: cursor.ID,
+			Valid: true,
+		}
+	}
 	getPosts := database.GetPostsForUserParams{
 		UserID: user.ID,
+		UnreadOnly: *unread,
+		Feeds: feeds,
+		Since: sinceTime,
+		Until: untilTime,
+		Keyword: keywordFilter,
+		BeforeID: beforeID,
+		Sort: *sortOrder,
 		Limit: limit,
+		Offset: int32(*page-1) * limit,
 	}
 	posts, err := s.db.GetPostsForUser(context.Background(), getPosts)
 	if err != nil {
 		return fmt.Errorf("error retrieving posts: %w", err)
 	}
 	if len(posts) == 0 {
+		if *page > 1 || *before != "" {
+			fmt.Println("No more posts.")
+			return nil
+		}
+		if len(feeds) > 0 || sinceTime.Valid || untilTime.Valid || keywordFilter.Valid {
+			fmt.Println("No posts found matching the given filters.")
+			return nil
+		}
+		if *unread {
+			fmt.Println("No unread posts found.")
+			return nil
+		}
     	fmt.Println("No posts found. You might not be following any feeds, or the feeds don't have any posts yet.")
     	return nil 
 	}
-	fmt.Printf("Showing %d most recent posts from your feeds:\n\n", limit)
+	if *page > 1 || *before != "" {
+		fmt.Printf("Showing %d more posts from your feeds:\n\n", len(posts))
+	} else if *sortOrder == "oldest" {
+		fmt.Printf("Showing %d oldest posts from your feeds:\n\n", limit)
+	} else if *unread {
+		fmt.Printf("Showing %d most recent unread posts from your feeds:\n\n", limit)
+	} else {
+		fmt.Printf("Showing %d most recent posts from your feeds:\n\n", limit)
+	}
 
-	for _, post := range posts {
+	for i, post := range posts {
+		if *sortOrder == "feed" && (i == 0 || post.FeedID != posts[i-1].FeedID) {	//Header at the start of each feed's group
+			fmt.Printf("=== %s ===\n\n", post.FeedName)
+		}
 		if post.Title.Valid {
         	fmt.Printf(" ** %s **\n", post.Title.String)
     	} else {
         	fmt.Println(" ** [No Title] **")
     	}
-		fmt.Printf(" ** Published: %v\n", post.PublishedAt.Format("Jan 2, 2006 at 3:04 PM"))
+		fmt.Printf(" ** ID: %s\n", post.ShortID)
+		fmt.Printf(" ** Link: %s\n", post.Url)
+		if post.UpdatedSinceSeen {	//Publisher edited the post since browse last showed it to the user
+			fmt.Println(" ** [Updated] **")
+		}
+		if post.DateEstimated {
+			fmt.Printf(" ** Published: %v (estimated)\n", post.PublishedAt.Format("Jan 2, 2006 at 3:04 PM"))
+		} else {
+			fmt.Printf(" ** Published: %v\n", post.PublishedAt.Format("Jan 2, 2006 at 3:04 PM"))
+		}
 		fmt.Println(" ~~~~~~~~~~")
 		if post.Description.Valid {
         	fmt.Printf(" %s\n", post.Description.String)
@@ -83,117 +192,489 @@ func handlerBrowse(s *state, cmd command, user database.User) error {	//Browses
         	fmt.Println(" [No Description]")
     	}
 		fmt.Println(" ~~~~~~~~~~")
+		if err := printEnclosures(s, post.ID); err != nil {
+			return err
+		}
+
+		seenParams := database.MarkPostSeenParams{	//Updated markers are relative to when each post was last shown
+			UserID: user.ID,
+			PostID: post.ID,
+		}
+		if err := s.db.MarkPostSeen(context.Background(), seenParams); err != nil {
+			return fmt.Errorf("error marking post as seen: %w", err)
+		}
+
+		if *markRead {
+			readParams := database.MarkPostReadParams{
+				UserID: user.ID,
+				PostID: post.ID,
+			}
+			if err := s.db.MarkPostRead(context.Background(), readParams); err != nil {
+				return fmt.Errorf("error marking post as read: %w", err)
+			}
+		}
+	}
+
+	if len(posts) == int(limit) {	//A full page, there may be more
+		fmt.Printf("Next page: browse --before %s (with the same limit, sort and filters)\n", posts[len(posts)-1].ShortID)
 	}
+
+	return nil
+}
+
+var browseSorts = map[string]bool{	//Sort orders browse accepts, ordering is done by GetPostsForUser
+	"newest": true,
+	"oldest": true,
+	"created": true,
+	"feed": true,
+}
+
+type stringList []string	//Flag value that collects every use of a repeatable flag
+
+func (list *stringList) String() string {
+	return strings.Join(*list, ", ")
+}
+
+func (list *stringList) Set(value string) error {
+	*list = append(*list, value)
 	return nil
 }
 
-func scrapeFeeds(s *state) error {	//Grabs feeds from the feeds table, and sends fetch requests based on time since last fetched
-	feedToFetch, err := s.db.GetNextFeedToFetch(context.Background())	//Grabs a feed from feeds that current user follows
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)	//Escapes LIKE wildcards, so keywords match literally
+
+func parseDateFlag(value string, endOfDay bool) (sql.NullTime, error) {	//Parses a date flag, either a date, a full timestamp or a duration ago (48h), endOfDay makes a plain date include the whole day
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	if ago, err := time.ParseDuration(value); err == nil {
+		return sql.NullTime{Time: time.Now().UTC().Add(-ago), Valid: true}, nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			date = date.AddDate(0, 0, 1)
+		}
+		return sql.NullTime{Time: date.UTC(), Valid: true}, nil
+	}
+	timestamp, err := time.Parse(time.RFC3339, value)
 	if err != nil {
-		return fmt.Errorf("error getting feed to fetch: %w", err)
+		return sql.NullTime{}, fmt.Errorf("expected a date (2006-01-02), timestamp (2006-01-02T15:04:05Z) or duration (48h): %q", value)
 	}
-	err = s.db.MarkFeedFetched(context.Background(), feedToFetch.ID)	//Marks it fetched at time
+	return sql.NullTime{Time: timestamp.UTC(), Valid: true}, nil
+}
+
+func handlerSearch(s *state, cmd command, user database.User) error {	//Full-text searches posts from followed feeds, best matches first - takes query input, and --all/--limit flags
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	all := flags.Bool("all", false, "search posts from every feed, not only followed ones")
+	limit := flags.Int("limit", 10, "max number of results")
+	args, err := parseFlags(flags, cmd.args)
 	if err != nil {
-		return fmt.Errorf("error marking feed as fetched: %w", err)
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("missing search query")
+	}
+	query := strings.Join(args, " ")
+	if *limit < 1 {
+		return fmt.Errorf("limit must be at least 1")
 	}
 
-	feed, fetchErr := fetchFeed(context.Background(), feedToFetch.Url)	//Fetches contents
-	if fetchErr != nil {
-		return fmt.Errorf("error fetching rss feed of url: %s: %w", feedToFetch.Url, fetchErr)
+	searchParams := database.SearchPostsParams{
+		Query: query,
+		AllFeeds: *all,
+		UserID: user.ID,
+		Limit: int32(*limit),
 	}
-	fmt.Println("~~~~~~~~~~~~~~~~~~~~")
-	fmt.Printf("Feed: %s\n", feed.Channel.Title)	//Prints contents
-	if len(feed.Channel.Item) == 0 {
-		fmt.Printf(" ~~ No posts in %s ~~\n", feed.Channel.Title)
+	posts, err := s.db.SearchPosts(context.Background(), searchParams)
+	if err != nil {
+		return fmt.Errorf("error searching posts: %w", err)
+	}
+	if len(posts) == 0 {
+		fmt.Printf("No posts found matching %q.\n", query)
+		return nil
 	}
-	var added, skipped int
-	
-	for _, item := range feed.Channel.Item {	//Iterates over posts in feed
-		parsedDate, err := parseDate(item.PubDate)	//Parses publication date
-		if err != nil {
-			return fmt.Errorf("error parsing post's publication date: %w", err)
-		}
 
-		// For a string that might be empty or nil
-		var title sql.NullString
-		if item.Title != "" {
-			title = sql.NullString{
-				String: item.Title,
-				Valid: true,
-			}
+	for _, post := range posts {
+		if post.Title.Valid {
+			fmt.Printf(" ** %s **\n", post.Title.String)
 		} else {
-			title = sql.NullString{
-				Valid: false,
-			}
+			fmt.Println(" ** [No Title] **")
 		}
-		// Same for description
-		var description sql.NullString
-		if item.Description != "" {
-			description = sql.NullString{
-				String: item.Description,
-				Valid: true,
-			}
+		fmt.Printf(" ** ID: %s\n", post.ShortID)
+		fmt.Printf(" ** Feed: %s\n", post.FeedName)
+		fmt.Printf(" ** Link: %s\n", post.Url)
+		fmt.Printf(" ** Published: %v\n", post.PublishedAt.Format("Jan 2, 2006 at 3:04 PM"))
+		fmt.Println(" ~~~~~~~~~~")
+	}
+	return nil
+}
+
+func handlerShow(s *state, cmd command) error {	//Shows a post in full, with its author, categories, comments link and content - takes post id input
+	argErr := argCheck(cmd.args)	//Checks arguments
+	if argErr != nil {
+		return argErr
+	}
+	post, err := resolvePost(s, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	feed, err := s.db.GetFeedByID(context.Background(), post.FeedID)
+	if err != nil {
+		return fmt.Errorf("error getting feed data: %w", err)
+	}
+	categories, err := s.db.GetPostCategories(context.Background(), post.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving post categories: %w", err)
+	}
+
+	fmt.Printf(" ** %s **\n", postTitle(post))
+	fmt.Printf(" ** ID: %s\n", post.ShortID)
+	fmt.Printf(" ** Feed: %s\n", feed.Name)
+	fmt.Printf(" ** Link: %s\n", post.Url)
+	if post.Author.Valid {
+		fmt.Printf(" ** Author: %s\n", post.Author.String)
+	}
+	if post.DateEstimated {
+		fmt.Printf(" ** Published: %v (estimated)\n", post.PublishedAt.Format("Jan 2, 2006 at 3:04 PM"))
+	} else {
+		fmt.Printf(" ** Published: %v\n", post.PublishedAt.Format("Jan 2, 2006 at 3:04 PM"))
+	}
+	if len(categories) > 0 {
+		fmt.Printf(" ** Categories: %s\n", strings.Join(categories, ", "))
+	}
+	if post.CommentsUrl.Valid {
+		fmt.Printf(" ** Comments: %s\n", post.CommentsUrl.String)
+	}
+	if err := printEnclosures(s, post.ID); err != nil {
+		return err
+	}
+	fmt.Println(" ~~~~~~~~~~")
+	if post.Description.Valid {
+		fmt.Printf(" %s\n", post.Description.String)
+	} else {
+		fmt.Println(" [No Description]")
+	}
+	if post.Content.Valid && post.Content.String != post.Description.String {	//Full content, when the feed gives more than the description
+		fmt.Println(" ~~~~~~~~~~")
+		fmt.Printf(" %s\n", post.Content.String)
+	}
+	fmt.Println(" ~~~~~~~~~~")
+	return nil
+}
+
+func handlerEnclosures(s *state, cmd command) error {	//Lists the media files attached to a feed's posts, with their sizes and durations - takes feed url input
+	argErr := argCheck(cmd.args)	//Checks arguments
+	if argErr != nil {
+		return argErr
+	}
+	feed, err := s.db.GetFeed(context.Background(), cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("error getting feed data: %w", err)
+	}
+	enclosures, err := s.db.GetEnclosuresForFeed(context.Background(), feed.Url)
+	if err != nil {
+		return fmt.Errorf("error retrieving enclosures: %w", err)
+	}
+	if len(enclosures) == 0 {
+		fmt.Printf("No media found in %s\n", feed.Name)
+		return nil
+	}
+
+	fmt.Printf("Media in %s:\n", feed.Name)
+	for _, enclosure := range enclosures {
+		title := "[No Title]"
+		if enclosure.PostTitle.Valid {
+			title = enclosure.PostTitle.String
+		}
+		if enclosure.Episode.Valid {
+			title = fmt.Sprintf("Episode %d: %s", enclosure.Episode.Int32, title)
+		}
+		fmt.Printf(" ** %s ~ %s\n", title, enclosure.PublishedAt.Format("Jan 2, 2006"))
+		fmt.Printf("    %s%s\n", enclosure.Url, mediaDetails(enclosure.MimeType, enclosure.Length, enclosure.Duration))
+	}
+	return nil
+}
+
+func printEnclosures(s *state, postID uuid.UUID) error {	//Prints the media files attached to a post, if any
+	enclosures, err := s.db.GetPostEnclosures(context.Background(), postID)
+	if err != nil {
+		return fmt.Errorf("error retrieving enclosures: %w", err)
+	}
+	for _, enclosure := range enclosures {
+		fmt.Printf(" ** Media: %s%s\n", enclosure.Url, mediaDetails(enclosure.MimeType, enclosure.Length, enclosure.Duration))
+	}
+	return nil
+}
+
+func mediaDetails(mimeType sql.NullString, length sql.NullInt64, duration sql.NullInt32) string {	//Formats the type, size and duration of a media file, leaving out unknown values
+	var details []string
+	if mimeType.Valid {
+		details = append(details, mimeType.String)
+	}
+	if length.Valid && length.Int64 > 0 {	//Publishers often give 0 when the size is unknown
+		details = append(details, formatSize(length.Int64))
+	}
+	if duration.Valid && duration.Int32 > 0 {
+		details = append(details, (time.Duration(duration.Int32) * time.Second).String())
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(details, ", ") + ")"
+}
+
+func formatSize(bytes int64) string {	//Formats a size in bytes with a readable unit
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	size := float64(bytes)
+	units := []string{"KB", "MB", "GB", "TB"}
+	i := -1
+	for size >= unit && i < len(units)-1 {
+		size /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", size, units[i])
+}
+
+func handlerRead(s *state, cmd command, user database.User) error {	//Marks a post as read for the current user - takes post id input
+	argErr := argCheck(cmd.args)	//Checks arguments
+	if argErr != nil {
+		return argErr
+	}
+	post, err := resolvePost(s, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	readParams := database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	}
+	if err := s.db.MarkPostRead(context.Background(), readParams); err != nil {
+		return fmt.Errorf("error marking post as read: %w", err)
+	}
+	fmt.Printf("Marked %s as read\n", postTitle(post))
+	return nil
+}
+
+func handlerUnread(s *state, cmd command, user database.User) error {	//Marks a post as unread for the current user - takes post id input
+	argErr := argCheck(cmd.args)	//Checks arguments
+	if argErr != nil {
+		return argErr
+	}
+	post, err := resolvePost(s, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	unreadParams := database.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	}
+	if err := s.db.MarkPostUnread(context.Background(), unreadParams); err != nil {
+		return fmt.Errorf("error marking post as unread: %w", err)
+	}
+	fmt.Printf("Marked %s as unread\n", postTitle(post))
+	return nil
+}
+
+func handlerMarkAllRead(s *state, cmd command, user database.User) error {	//Marks every post from followed feeds as read for the current user, takes optional feed url input
+	var feedURL sql.NullString
+	if len(cmd.args) > 0 {
+		if _, err := s.db.GetFeed(context.Background(), cmd.args[0]); err != nil {	//Checks the feed exists, so a typo isn't reported as nothing to mark
+			return fmt.Errorf("error getting feed data: %w", err)
+		}
+		feedURL = sql.NullString{
+			String: cmd.args[0],
+			Valid: true,
+		}
+	}
+
+	markParams := database.MarkAllPostsReadParams{
+		UserID: user.ID,
+		FeedUrl: feedURL,
+	}
+	count, err := s.db.MarkAllPostsRead(context.Background(), markParams)
+	if err != nil {
+		return fmt.Errorf("error marking posts as read: %w", err)
+	}
+	fmt.Printf("Marked %d posts as read\n", count)
+	return nil
+}
+
+func handlerStar(s *state, cmd command, user database.User) error {	//Stars a post for the current user, keeping it after the feed is unfollowed - takes post id input
+	argErr := argCheck(cmd.args)	//Checks arguments
+	if argErr != nil {
+		return argErr
+	}
+	post, err := resolvePost(s, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	starParams := database.StarPostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	}
+	if err := s.db.StarPost(context.Background(), starParams); err != nil {
+		return fmt.Errorf("error starring post: %w", err)
+	}
+	fmt.Printf("Starred %s\n", postTitle(post))
+	return nil
+}
+
+func handlerUnstar(s *state, cmd command, user database.User) error {	//Removes a star from a post for the current user - takes post id input
+	argErr := argCheck(cmd.args)	//Checks arguments
+	if argErr != nil {
+		return argErr
+	}
+	post, err := resolvePost(s, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	unstarParams := database.UnstarPostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	}
+	if err := s.db.UnstarPost(context.Background(), unstarParams); err != nil {
+		return fmt.Errorf("error unstarring post: %w", err)
+	}
+	fmt.Printf("Unstarred %s\n", postTitle(post))
+	return nil
+}
+
+func handlerStarred(s *state, cmd command, user database.User) error {	//Lists the current user's starred posts, most recently starred first
+	posts, err := s.db.GetStarredPosts(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving starred posts: %w", err)
+	}
+	if len(posts) == 0 {
+		fmt.Println("No starred posts.")
+		return nil
+	}
+
+	for _, post := range posts {
+		if post.Title.Valid {
+			fmt.Printf(" ** %s **\n", post.Title.String)
 		} else {
-			description = sql.NullString{
-				Valid: false,
-			}
+			fmt.Println(" ** [No Title] **")
 		}
+		fmt.Printf(" ** ID: %s\n", post.ShortID)
+		fmt.Printf(" ** Feed: %s\n", post.FeedName)
+		fmt.Printf(" ** Link: %s\n", post.Url)
+		fmt.Printf(" ** Starred: %v\n", post.StarredAt.Format("Jan 2, 2006 at 3:04 PM"))
+		fmt.Println(" ~~~~~~~~~~")
+	}
+	return nil
+}
 
-		newPost := database.CreatePostParams{	//Create post params
-			ID: uuid.New(),
-			CreatedAt: time.Now().UTC(),
-			UpdatedAt: time.Now().UTC(),
-			Title: title,
-			Url: item.Link,
-			Description: description,
-			PublishedAt: parsedDate,
-			FeedID: feedToFetch.ID,
+func resolvePost(s *state, arg string) (database.Post, error) {	//Finds the post a command argument refers to, either a full uuid or a short id
+	if id, err := uuid.Parse(arg); err == nil {
+		post, err := s.db.GetPost(context.Background(), id)
+		if err != nil {
+			return database.Post{}, fmt.Errorf("error getting post %s: %w", arg, err)
+		}
+		return post, nil
+	}
+
+	post, err := s.db.GetPostByShortID(context.Background(), strings.ToLower(arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Post{}, fmt.Errorf("no post found with id %s", arg)
+	}
+	if err != nil {
+		return database.Post{}, fmt.Errorf("error getting post %s: %w", arg, err)
+	}
+	return post, nil
+}
+
+func postTitle(post database.Post) string {	//Returns a post's title for output, or a placeholder when it has none
+	if post.Title.Valid {
+		return post.Title.String
+	}
+	return "[No Title]"
+}
+
+func handlerFetchLog(s *state, cmd command) error {	//Shows recent fetch attempts by agg, takes optional feed url input
+	const limit = 20
+	var attempts []database.GetFetchAttemptsRow
+	if len(cmd.args) == 0 {
+		rows, err := s.db.GetFetchAttempts(context.Background(), limit)
+		if err != nil {
+			return fmt.Errorf("error retrieving fetch log: %w", err)
 		}
-		_, err = s.db.CreatePost(context.Background(), newPost)	//Creates post in posts table
+		attempts = rows
+	} else {
+		logParams := database.GetFetchAttemptsForFeedParams{
+			Url: cmd.args[0],
+			Limit: limit,
+		}
+		rows, err := s.db.GetFetchAttemptsForFeed(context.Background(), logParams)
 		if err != nil {
-			if strings.Contains(err.Error(), "unique constraint") ||
-				strings.Contains(err.Error(), "duplicate key") {
-				skipped++
-				continue
-			} else {
-				return fmt.Errorf("error saving post to database: %w", err)
-			}
+			return fmt.Errorf("error retrieving fetch log for %s: %w", cmd.args[0], err)
 		}
-		if title.Valid {
-    		fmt.Printf(" ~~ %s ~~ Saved to database\n", title.String)
-		} else {
-    		fmt.Println(" ~~ [No Title] ~~ Saved to database")
+		for _, row := range rows {	//Both queries return the same columns
+			attempts = append(attempts, database.GetFetchAttemptsRow(row))
 		}
-		added++
 	}
-	fmt.Printf("Added %d new posts, skipped %d existing posts\n", added, skipped)
+	if len(attempts) == 0 {
+		fmt.Println("No fetch attempts recorded yet.")
+		return nil
+	}
 
+	for _, attempt := range attempts {
+		fmt.Printf("%s ~ %s\n", attempt.StartedAt.Format("Jan 2, 2006 at 3:04:05 PM"), attempt.FeedName)
+		status := "no response"
+		if attempt.HttpStatus.Valid {
+			status = fmt.Sprintf("HTTP %d, %d bytes", attempt.HttpStatus.Int32, attempt.Bytes.Int64)
+		}
+		fmt.Printf(" %s in %v\n", status, attempt.FinishedAt.Sub(attempt.StartedAt).Round(time.Millisecond))
+		fmt.Printf(" %d items seen, %d posts added, %d updated, %d skipped\n", attempt.ItemsSeen, attempt.PostsAdded, attempt.PostsUpdated, attempt.PostsSkipped)
+		if attempt.Error.Valid {
+			fmt.Printf(" Error: %s\n", attempt.Error.String)
+		}
+		fmt.Println("~~~~~~~~~~~~~~")
+	}
 	return nil
 }
 
-func parseDate(dateStr string) (time.Time, error) {	//Parse a datetime and return it in a parsed format for easier sorting
-	formats := []string{
-		time.RFC1123Z,
-		time.RFC1123,
-		time.RFC3339,
-		time.RFC822,
-		"2006-01-02T15:04:05Z",
-        "2006-01-02T15:04:05-07:00",
-        "2006-01-02T15:04:05+07:00",
-        "2006-01-02 15:04:05",
-        "Mon, 2 Jan 2006 15:04:05 MST",
-        "2 Jan 2006 15:04:05 -0700",
+func handlerSetInterval(s *state, cmd command) error {	//Sets how often a feed is fetched - takes url and duration input, 'default' resets to the agg interval
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("expected input: 'setinterval -url- -duration|default-")
 	}
-	var parsedTime time.Time
-	var err error
+	url := cmd.args[0]
 
-	for _, format := range formats {
-		parsedTime, err = time.Parse(format, dateStr)
-		if err == nil {
-			return parsedTime, nil
+	var fetchInterval sql.NullInt32
+	if cmd.args[1] != "default" {
+		interval, err := time.ParseDuration(cmd.args[1])
+		if err != nil {
+			return fmt.Errorf("error parsing duration string: %w", err)
+		}
+		if interval < time.Minute {
+			return fmt.Errorf("fetch interval must be at least 1m")
 		}
+		fetchInterval = sql.NullInt32{
+			Int32: int32(interval.Seconds()),
+			Valid: true,
+		}
+	}
+
+	intervalParams := database.SetFeedFetchIntervalParams{
+		FetchInterval: fetchInterval,
+		Url: url,
+	}
+	feed, err := s.db.SetFeedFetchInterval(context.Background(), intervalParams)
+	if err != nil {
+		return fmt.Errorf("error setting fetch interval for %s: %w", url, err)
+	}
+
+	if feed.FetchInterval.Valid {
+		fmt.Printf("%s will be fetched every %s\n", feed.Name, time.Duration(feed.FetchInterval.Int32)*time.Second)
+	} else {
+		fmt.Printf("%s will be fetched at the agg interval\n", feed.Name)
 	}
-	return time.Time{}, err
+	return nil
 }
 
 func handlerUnfollow(s *state, cmd command, user database.User) error {	//Unfollows a feed for the current user - takes url input
@@ -234,13 +715,24 @@ func handlerFollowing(s *state, cmd command, user database.User) error {	//Lists
 	return nil
 }
 
-func handlerFollow(s *state, cmd command, user database.User) error {	//Sets the current user to be following a given feed
+func handlerFollow(s *state, cmd command, user database.User) error {	//Sets the current user to be following a given feed, takes a feed or website url
 	if len(cmd.args) == 0 {	//Checks arguments
 		return fmt.Errorf("missing feed url")
 	}
 	url := cmd.args[0]
 
 	feed, err := s.db.GetFeed(context.Background(), url)	//Gets feed data from feeds table
+	if errors.Is(err, sql.ErrNoRows) {	//Not a known feed url, it may be a website whose feed was added
+		candidate, findErr := findFeed(context.Background(), url, os.Stdin, os.Stdout)
+		if findErr != nil {
+			return fmt.Errorf("error finding feed for %s: %w", url, findErr)
+		}
+		url = candidate.URL
+		feed, err = s.db.GetFeed(context.Background(), url)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("feed %s is not in the database, add it with addfeed", url)
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("error getting feed data: %w", err)
 	}
@@ -263,7 +755,14 @@ func handlerFollow(s *state, cmd command, user database.User) error {	//Sets the
 	return nil
 }
 
-func handlerFeeds(s *state, cmd command) error {	//Returns list of feeds in database
+func handlerFeeds(s *state, cmd command) error {	//Returns list of feeds in database, '--failing' lists only feeds that are disabled or failing
+	if len(cmd.args) > 0 {
+		if cmd.args[0] != "--failing" {
+			return fmt.Errorf("unknown flag: %s", cmd.args[0])
+		}
+		return listFailingFeeds(s)
+	}
+
 	feeds, err := s.db.GetFeeds(context.Background())	//Gets feeds from feeds table
 	if err != nil {
 		return fmt.Errorf("error retrieving feeds: %w", err)
@@ -277,19 +776,98 @@ func handlerFeeds(s *state, cmd command) error {	//Returns list of feeds in data
 
 		fmt.Println(feed.Name)
 		fmt.Println(feed.Url)
+		if feed.Link.Valid {
+			fmt.Printf("Site: %s\n", feed.Link.String)
+		}
+		if feed.Description.Valid {
+			fmt.Println(feed.Description.String)
+		}
 		fmt.Println(userName)
 		fmt.Println("~~~~~~~~~~~~~~")
 	}
 	return nil
 }
 
-func handlerAddFeed(s *state, cmd command, user database.User) error {	//Creates a feed in database
-	if len(cmd.args) < 2 {	//Checks arguments
-		return fmt.Errorf("expected input: 'addfeed -feedname- -url-")
+func listFailingFeeds(s *state) error {	//Lists feeds that are disabled or have failed their latest fetches
+	feeds, err := s.db.GetFailingFeeds(context.Background())
+	if err != nil {
+		return fmt.Errorf("error retrieving failing feeds: %w", err)
+	}
+	if len(feeds) == 0 {
+		fmt.Println("No feeds are failing.")
+		return nil
 	}
 
-	feedname := cmd.args[0]
-	url := cmd.args[1]
+	for _, feed := range feeds {
+		fmt.Println(feed.Name)
+		fmt.Println(feed.Url)
+		if feed.Disabled {
+			fmt.Printf("Disabled after %d consecutive failures\n", feed.ConsecutiveFailures)
+		} else {
+			fmt.Printf("Failing, %d consecutive failures\n", feed.ConsecutiveFailures)
+		}
+		if feed.LastError.Valid {
+			fmt.Printf("Last error: %s\n", feed.LastError.String)
+		}
+		if feed.LastSuccessAt.Valid {
+			fmt.Printf("Last success: %v\n", feed.LastSuccessAt.Time.Format("Jan 2, 2006 at 3:04 PM"))
+		} else {
+			fmt.Println("Last success: never")
+		}
+		fmt.Println("~~~~~~~~~~~~~~")
+	}
+	return nil
+}
+
+func handlerEnableFeed(s *state, cmd command) error {	//Re-enables a feed disabled after repeated failures - takes url input
+	argErr := argCheck(cmd.args)	//Checks arguments
+	if argErr != nil {
+		return argErr
+	}
+
+	feed, err := s.db.EnableFeed(context.Background(), cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("error enabling feed %s: %w", cmd.args[0], err)
+	}
+	fmt.Printf("%s enabled, it will be fetched on the next agg tick\n", feed.Name)
+	return nil
+}
+
+func handlerAddFeed(s *state, cmd command, user database.User) error {	//Creates a feed in database, takes optional name and a feed or website url, naming the feed after its channel title when no name is given
+	var feedname, pageURL string
+	switch len(cmd.args) {	//Checks arguments
+	case 1:
+		pageURL = cmd.args[0]
+	case 2:
+		feedname = cmd.args[0]
+		pageURL = cmd.args[1]
+	default:
+		return fmt.Errorf("expected input: 'addfeed -feedname(optional)- -url-")
+	}
+
+	candidate, err := findFeed(context.Background(), pageURL, os.Stdin, os.Stdout)	//Accepts a website url, finding the feed it links to
+	if err != nil {
+		return fmt.Errorf("error finding feed for %s: %w", pageURL, err)
+	}
+	url := candidate.URL
+	rssFeed := candidate.Feed
+	if rssFeed == nil {	//Linked from a web page but not fetched yet, checks it really is a feed before saving it
+		result, err := fetchFeed(context.Background(), url, "", "")
+		if err != nil {
+			return fmt.Errorf("invalid feed %s: %w", url, err)
+		}
+		if result.Feed == nil {	//No conditional headers were sent, but a server can still answer without a body
+			return fmt.Errorf("invalid feed %s: no feed content returned", url)
+		}
+		rssFeed = result.Feed
+	}
+
+	if feedname == "" {
+		feedname = strings.TrimSpace(rssFeed.Title)
+		if feedname == "" {
+			return fmt.Errorf("feed %s has no title, give a name: 'addfeed -feedname- -url-", url)
+		}
+	}
 
 	newFeed := database.CreateFeedParams{	//Set feed params
 		ID: uuid.New(),
@@ -298,6 +876,8 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {	//Creates
 		Name: feedname,
 		Url: url,
 		UserID: user.ID,
+		Link: nullString(strings.TrimSpace(rssFeed.Link)),
+		Description: nullString(strings.TrimSpace(rssFeed.Description)),
 	}
 
 	feed, err := s.db.CreateFeed(context.Background(), newFeed)	//Create feed in feeds table
@@ -323,6 +903,138 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {	//Creates
 	return nil
 }
 
+func handlerImportOPML(s *state, cmd command, user database.User) error {	//Adds and follows every feed in an OPML file for the current user - takes file path input
+	argErr := argCheck(cmd.args)	//Checks arguments
+	if argErr != nil {
+		return argErr
+	}
+	data, err := os.ReadFile(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("error reading opml file: %w", err)
+	}
+	opmlFeeds, err := parseOPML(data)
+	if err != nil {
+		return err
+	}
+
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving followed feeds: %w", err)
+	}
+	following := make(map[uuid.UUID]bool)
+	for _, follow := range follows {
+		following[follow.FeedID] = true
+	}
+
+	var created, existing, failed int
+	for _, opmlFeed := range opmlFeeds {
+		feed, isNew, err := importFeed(s, user, opmlFeed, following)
+		if err != nil {
+			fmt.Printf("Failed: %s (%s): %v\n", opmlFeed.Name, opmlFeed.URL, err)
+			failed++
+			continue
+		}
+		following[feed.ID] = true
+		if isNew {
+			fmt.Printf("Created: %s\n", feed.Name)
+			created++
+		} else {
+			fmt.Printf("Already exists: %s\n", feed.Name)
+			existing++
+		}
+	}
+	fmt.Printf("Imported %d feeds: %d created, %d already existing, %d failed\n", len(opmlFeeds), created, existing, failed)
+	return nil
+}
+
+func handlerExportOPML(s *state, cmd command, user database.User) error {	//Writes the current user's follows as an OPML file - takes optional file path input, printing to stdout without one
+	if len(cmd.args) > 1 {
+		return fmt.Errorf("too many arguments given, expecting optional file path")
+	}
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving follows for %s: %w", user.Name, err)
+	}
+	sort.Slice(follows, func(i, j int) bool {	//Groups feeds of the same folder together
+		if follows[i].Folder.String != follows[j].Folder.String {
+			return follows[i].Folder.String < follows[j].Folder.String
+		}
+		return follows[i].Name < follows[j].Name
+	})
+
+	var opmlFeeds []opmlFeed
+	for _, follow := range follows {
+		opmlFeeds = append(opmlFeeds, opmlFeed{
+			Name: follow.Name,
+			URL: follow.Url,
+			SiteURL: follow.Link.String,
+			Folder: follow.Folder.String,
+		})
+	}
+	data, err := buildOPML(fmt.Sprintf("gator subscriptions of %s", user.Name), opmlFeeds)
+	if err != nil {
+		return err
+	}
+
+	if len(cmd.args) == 0 {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(cmd.args[0], data, 0644); err != nil {
+		return fmt.Errorf("error writing opml file: %w", err)
+	}
+	fmt.Printf("Exported %d feeds to %s\n", len(opmlFeeds), cmd.args[0])
+	return nil
+}
+
+func importFeed(s *state, user database.User, opmlFeed opmlFeed, following map[uuid.UUID]bool) (database.Feed, bool, error) {	//Creates an imported feed if missing and follows it, reporting whether the feed was created
+	isNew := false
+	feed, err := s.db.GetFeed(context.Background(), opmlFeed.URL)
+	if errors.Is(err, sql.ErrNoRows) {
+		newFeed := database.CreateFeedParams{
+			ID: uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			Name: opmlFeed.Name,
+			Url: opmlFeed.URL,
+			UserID: user.ID,
+			Link: nullString(opmlFeed.SiteURL),
+		}
+		feed, err = s.db.CreateFeed(context.Background(), newFeed)
+		if err != nil {
+			return database.Feed{}, false, fmt.Errorf("error creating new feed: %w", err)
+		}
+		isNew = true
+	} else if err != nil {
+		return database.Feed{}, false, fmt.Errorf("error getting feed data: %w", err)
+	}
+
+	if !following[feed.ID] {
+		feedFollowParams := database.CreateFeedFollowParams{
+			ID: uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			UserID: user.ID,
+			FeedID: feed.ID,
+		}
+		if _, err := s.db.CreateFeedFollow(context.Background(), feedFollowParams); err != nil {
+			return database.Feed{}, false, fmt.Errorf("error following feed: %w", err)
+		}
+	}
+
+	if opmlFeed.Folder != "" {
+		folderParams := database.SetFeedFollowFolderParams{
+			UserID: user.ID,
+			FeedID: feed.ID,
+			Folder: nullString(opmlFeed.Folder),
+		}
+		if err := s.db.SetFeedFollowFolder(context.Background(), folderParams); err != nil {
+			return database.Feed{}, false, fmt.Errorf("error saving folder: %w", err)
+		}
+	}
+	return feed, isNew, nil
+}
+
 func handlerUsers(s *state, cmd command) error {	//Returns list of users
 	users, err := s.db.ListUsers(context.Background())	//Retrieves all user data from users table
 	if err != nil {
@@ -412,6 +1124,25 @@ func (c *commands) run(s *state, cmd command) error {	//Runs command in main
 	return command(s, cmd)
 }
 
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {	//Parses flags given anywhere among the arguments, returning the positional arguments left over
+	flags.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, fmt.Errorf("%s: %w (put -- before arguments starting with a dash)", flags.Name(), err)
+		}
+		rest := flags.Args()
+		if parsed := len(args) - len(rest); parsed > 0 && args[parsed-1] == "--" {	//Parsing stopped at --, everything after it is positional even when it starts with a dash
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
 func argCheck(args []string) error {	//Error check for arg input
 	if len(args) == 0 {
 		return fmt.Errorf("no command input")
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDateFlag(t *testing.T) {
+	day := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.Local).UTC()
+
+	tests := []struct {
+		name     string
+		value    string
+		endOfDay bool
+		want     sql.NullTime
+		wantErr  bool
+	}{
+		{name: "unset", value: ""},
+		{name: "plain date", value: "2024-05-01", want: sql.NullTime{Time: day, Valid: true}},
+		{name: "plain date to end of day", value: "2024-05-01", endOfDay: true, want: sql.NullTime{Time: day.AddDate(0, 0, 1), Valid: true}},
+		{name: "timestamp", value: "2024-05-01T15:04:05Z", want: sql.NullTime{Time: time.Date(2024, time.May, 1, 15, 4, 5, 0, time.UTC), Valid: true}},
+		{name: "timestamp ignores end of day", value: "2024-05-01T15:04:05+02:00", endOfDay: true, want: sql.NullTime{Time: time.Date(2024, time.May, 1, 13, 4, 5, 0, time.UTC), Valid: true}},
+		{name: "invalid", value: "last tuesday", wantErr: true},
+		{name: "invalid date", value: "2024-13-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDateFlag(tt.value, tt.endOfDay)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Valid != tt.want.Valid || !got.Time.Equal(tt.want.Time) {
+				t.Errorf("parseDateFlag(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateFlagDurationAgo(t *testing.T) {
+	before := time.Now().Add(-48 * time.Hour)
+	got, err := parseDateFlag("48h", false)
+	after := time.Now().Add(-48 * time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Valid || got.Time.Before(before) || got.Time.After(after) {
+		t.Errorf("parseDateFlag(48h) = %v, want between %v and %v", got, before, after)
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		wantPositional []string
+		wantAll        bool
+		wantLimit      int
+		wantErr        bool
+	}{
+		{name: "no arguments", args: nil, wantLimit: 10},
+		{name: "flags before query", args: []string{"--all", "--limit", "5", "rust"}, wantPositional: []string{"rust"}, wantAll: true, wantLimit: 5},
+		{name: "flags among query words", args: []string{"rust", "--limit=3", "async", "--all"}, wantPositional: []string{"rust", "async"}, wantAll: true, wantLimit: 3},
+		{name: "dash word without terminator", args: []string{"rust", "-async"}, wantErr: true},
+		{name: "dash word after terminator", args: []string{"--", "rust", "-async"}, wantPositional: []string{"rust", "-async"}, wantLimit: 10},
+		{name: "flags before terminator", args: []string{"rust", "--all", "--", "-async", "--limit", "3"}, wantPositional: []string{"rust", "-async", "--limit", "3"}, wantAll: true, wantLimit: 10},
+		{name: "unknown flag", args: []string{"--nope"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("search", flag.ContinueOnError)
+			all := flags.Bool("all", false, "")
+			limit := flags.Int("limit", 10, "")
+			got, err := parseFlags(flags, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.wantPositional) {
+				t.Errorf("positional = %q, want %q", got, tt.wantPositional)
+			}
+			if *all != tt.wantAll || *limit != tt.wantLimit {
+				t.Errorf("all = %v, limit = %d, want %v, %d", *all, *limit, tt.wantAll, tt.wantLimit)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type feedCandidate struct { //A feed found for a website, Feed is set when it was already fetched and parsed
+	URL   string
+	Title string
+	Feed  *parsedFeed
+}
+
+var feedLinkTypes = map[string]bool{ //Link types of rel="alternate" tags that point at feeds
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+}
+
+var wellKnownFeedPaths = []string{"/feed", "/rss.xml", "/atom.xml", "/feed.xml", "/index.xml"} //Tried when a page doesn't advertise its feeds
+
+var (
+	linkTagPattern   = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	attributePattern = regexp.MustCompile(`(?s)([a-zA-Z:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+func discoverFeeds(ctx context.Context, pageURL string) ([]feedCandidate, error) { //Finds the feeds for a url, the url itself when it is a feed, otherwise the feeds a web page links to or serves at well-known paths
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error forming request: %w", err)
+	}
+	req.Header.Set("User-Agent", "gator")
+
+	res, err := defaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch failed for URL %s: status %d", pageURL, res.StatusCode)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	if feed, err := parseFeed(body, res.Header.Get("Content-Type")); err == nil {
+		feedUnescape(feed)
+		return []feedCandidate{{URL: pageURL, Title: feed.Title, Feed: feed}}, nil
+	}
+
+	base := res.Request.URL //Final url after redirects, relative links resolve against it
+	candidates := linkedFeeds(string(body), base)
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+
+	for _, path := range wellKnownFeedPaths {
+		feedURL := base.ResolveReference(&url.URL{Path: path}).String()
+		result, err := fetchFeed(ctx, feedURL, "", "")
+		if err != nil || result.Feed == nil {
+			continue
+		}
+		candidates = append(candidates, feedCandidate{URL: feedURL, Title: result.Feed.Title, Feed: result.Feed})
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no feeds found at %s", pageURL)
+	}
+	return candidates, nil
+}
+
+func linkedFeeds(page string, base *url.URL) []feedCandidate { //Collects the feeds advertised by <link rel="alternate"> tags in an html page
+	var candidates []feedCandidate
+	seen := make(map[string]bool)
+	for _, tag := range linkTagPattern.FindAllString(page, -1) {
+		attrs := make(map[string]string)
+		for _, match := range attributePattern.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(match[1])] = html.UnescapeString(match[2] + match[3] + match[4])
+		}
+
+		isAlternate := false
+		for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+			if rel == "alternate" {
+				isAlternate = true
+			}
+		}
+		linkType := strings.ToLower(strings.TrimSpace(attrs["type"]))
+		if !isAlternate || !feedLinkTypes[linkType] || attrs["href"] == "" {
+			continue
+		}
+
+		href, err := base.Parse(strings.TrimSpace(attrs["href"]))
+		if err != nil || seen[href.String()] {
+			continue
+		}
+		seen[href.String()] = true
+		candidates = append(candidates, feedCandidate{URL: href.String(), Title: strings.TrimSpace(attrs["title"])})
+	}
+	return candidates
+}
+
+func chooseFeed(candidates []feedCandidate, in io.Reader, out io.Writer) (feedCandidate, error) { //Picks the only candidate, or asks the user to choose between several
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	fmt.Fprintln(out, "Found several feeds:")
+	for i, candidate := range candidates {
+		if candidate.Title != "" {
+			fmt.Fprintf(out, " %d. %s (%s)\n", i+1, candidate.Title, candidate.URL)
+		} else {
+			fmt.Fprintf(out, " %d. %s\n", i+1, candidate.URL)
+		}
+	}
+	fmt.Fprintf(out, "Choose a feed (1-%d): ", len(candidates))
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		return feedCandidate{}, fmt.Errorf("error reading choice: %w", err)
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || choice < 1 || choice > len(candidates) {
+		return feedCandidate{}, fmt.Errorf("invalid choice %q", strings.TrimSpace(line))
+	}
+	return candidates[choice-1], nil
+}
+
+func findFeed(ctx context.Context, pageURL string, in io.Reader, out io.Writer) (feedCandidate, error) { //Discovers the feeds for a url and settles on one, telling the user when it differs from the url given
+	candidates, err := discoverFeeds(ctx, pageURL)
+	if err != nil {
+		return feedCandidate{}, err
+	}
+	candidate, err := chooseFeed(candidates, in, out)
+	if err != nil {
+		return feedCandidate{}, err
+	}
+	if candidate.URL != pageURL {
+		fmt.Fprintf(out, "Using feed %s\n", candidate.URL)
+	}
+	return candidate, nil
+}
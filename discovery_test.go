@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLinkedFeeds(t *testing.T) {
+	base, err := url.Parse("https://example.com/blog/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		page string
+		want []feedCandidate
+	}{
+		{
+			name: "double quoted",
+			page: `<link rel="alternate" type="application/rss+xml" title="Posts" href="https://example.com/feed.xml">`,
+			want: []feedCandidate{{URL: "https://example.com/feed.xml", Title: "Posts"}},
+		},
+		{
+			name: "rel list",
+			page: `<link rel="alternate home" type="application/atom+xml" href="/atom.xml">`,
+			want: []feedCandidate{{URL: "https://example.com/atom.xml"}},
+		},
+		{
+			name: "single quoted and unquoted",
+			page: `<LINK REL='Alternate' TYPE='application/feed+json' HREF='feed.json'><link rel=alternate type=application/rss+xml href=rss.xml />`,
+			want: []feedCandidate{{URL: "https://example.com/blog/feed.json"}, {URL: "https://example.com/blog/rss.xml"}},
+		},
+		{
+			name: "relative href and escaped query",
+			page: `<link rel="alternate" type="application/rss+xml" href="../feed?format=rss&amp;lang=en">`,
+			want: []feedCandidate{{URL: "https://example.com/feed?format=rss&lang=en"}},
+		},
+		{
+			name: "duplicates",
+			page: `<link rel="alternate" type="application/rss+xml" href="/feed.xml"><link rel="alternate" type="application/rss+xml" href="https://example.com/feed.xml">`,
+			want: []feedCandidate{{URL: "https://example.com/feed.xml"}},
+		},
+		{
+			name: "not feeds",
+			page: `<link rel="alternate" type="text/html" hreflang="fr" href="/fr/"><link rel="stylesheet" type="text/css" href="/style.css"><link rel="alternate" type="application/rss+xml"><link rel="feed" type="application/rss+xml" href="/other.xml">`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := linkedFeeds("<html><head>"+tt.page+"</head></html>", base)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d candidates, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i].URL != tt.want[i].URL || got[i].Title != tt.want[i].Title {
+					t.Errorf("candidate %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoverFeedsAfterRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/blog/", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/blog/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><link rel="alternate" type="application/atom+xml" href="feed.atom"></head></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	candidates, err := discoverFeeds(context.Background(), server.URL+"/old")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].URL != server.URL+"/blog/feed.atom" {
+		t.Errorf("candidates = %+v, want the feed relative to /blog/", candidates)
+	}
+}
+
+func TestDiscoverFeedsDirectFeed(t *testing.T) {
+	feedData := readTestdata(t, "feed.atom")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml")
+		w.Write(feedData)
+	}))
+	defer server.Close()
+
+	candidates, err := discoverFeeds(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].URL != server.URL || candidates[0].Feed == nil {
+		t.Fatalf("candidates = %+v, want the url itself with its parsed feed", candidates)
+	}
+	if candidates[0].Title != "Example Blog" {
+		t.Errorf("title = %q, want the feed title", candidates[0].Title)
+	}
+}
+
+func TestChooseFeed(t *testing.T) {
+	candidates := []feedCandidate{
+		{URL: "https://example.com/feed.xml", Title: "Posts"},
+		{URL: "https://example.com/comments.xml"},
+	}
+
+	tests := []struct {
+		name       string
+		candidates []feedCandidate
+		input      string
+		wantURL    string
+		wantErr    bool
+	}{
+		{name: "single candidate needs no input", candidates: candidates[:1], wantURL: "https://example.com/feed.xml"},
+		{name: "first", candidates: candidates, input: "1\n", wantURL: "https://example.com/feed.xml"},
+		{name: "second with spaces", candidates: candidates, input: " 2 \n", wantURL: "https://example.com/comments.xml"},
+		{name: "choice at end of input", candidates: candidates, input: "2", wantURL: "https://example.com/comments.xml"},
+		{name: "out of range", candidates: candidates, input: "3\n", wantErr: true},
+		{name: "zero", candidates: candidates, input: "0\n", wantErr: true},
+		{name: "not a number", candidates: candidates, input: "posts\n", wantErr: true},
+		{name: "no input", candidates: candidates, input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := chooseFeed(tt.candidates, strings.NewReader(tt.input), &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.URL != tt.wantURL {
+				t.Errorf("chose %q, want %q", got.URL, tt.wantURL)
+			}
+			if len(tt.candidates) > 1 && !strings.Contains(out.String(), "1. Posts (https://example.com/feed.xml)") {
+				t.Errorf("prompt does not list the candidates: %q", out.String())
+			}
+		})
+	}
+}
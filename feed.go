@@ -0,0 +1,37 @@
+package main
+
+type parsedFeed struct { //A feed in any of the supported formats, mapped into the fields gator stores
+	Title           string
+	Link            string
+	Description     string
+	PubDate         string
+	LastBuildDate   string
+	TTL             string
+	SkipHours       []string
+	SkipDays        []string
+	UpdatePeriod    string
+	UpdateFrequency string
+	Items           []feedItem
+}
+
+type feedItem struct { //A post in any of the supported formats, fields a format lacks are left empty
+	Title       string
+	Link        string
+	Description string
+	PubDate     string
+	GUID        string
+	Content     string
+	Author      string
+	Comments    string
+	Categories  []string
+	Enclosures  []feedEnclosure
+	Duration    string
+	Episode     string
+}
+
+type feedEnclosure struct { //Media file attached to a post
+	URL      string
+	Length   string
+	Type     string
+	Duration string
+}
@@ -10,10 +10,13 @@ import (
 type Config struct {	
 	DbUrl	string `json:"db_url"`
 	CurrentUserName	string	`json:"current_user_name"`
+	MaxFeedFailures	int	`json:"max_feed_failures,omitempty"`
 }
 
 const configFileName = ".gatorconfig.json"	//Name of config json file
 
+const defaultMaxFeedFailures = 10	//Consecutive failed fetches before a feed is disabled, if not set in config
+
 func Read() (Config, error) {	//Reads config json file into Config struct
 	jsonFile, err := getConfigFilePath()
 	if err != nil {
@@ -53,6 +56,13 @@ func (c *Config) SetUser(name string) error {	//Sets user of config struct
 	return nil
 }
 
+func (c *Config) FeedFailureLimit() int {	//Returns the number of consecutive failures after which agg disables a feed
+	if c.MaxFeedFailures > 0 {
+		return c.MaxFeedFailures
+	}
+	return defaultMaxFeedFailures
+}
+
 func getConfigFilePath() (string, error) {	//Helper function to return the path for the config json file
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
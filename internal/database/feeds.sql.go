@@ -7,31 +7,106 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const claimFeedsToFetch = `-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET last_fetched_at = now(),
+    claimed_until = now() + $1::integer * interval '1 second',
+    updated_at = now()
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE NOT disabled
+    AND (next_fetch_at IS NULL OR next_fetch_at <= now())
+    AND (claimed_until IS NULL OR claimed_until <= now())
+    ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval, next_fetch_at, ttl, skip_hours, skip_days, update_period, update_frequency, consecutive_failures, last_error, last_success_at, disabled, link, description, claimed_until
+`
+
+type ClaimFeedsToFetchParams struct {
+	LeaseSeconds int32
+	BatchSize    int32
+}
+
+func (q *Queries) ClaimFeedsToFetch(ctx context.Context, arg ClaimFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, claimFeedsToFetch, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.FetchInterval,
+			&i.NextFetchAt,
+			&i.Ttl,
+			pq.Array(&i.SkipHours),
+			pq.Array(&i.SkipDays),
+			&i.UpdatePeriod,
+			&i.UpdateFrequency,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.Disabled,
+			&i.Link,
+			&i.Description,
+			&i.ClaimedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, link, description)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval, next_fetch_at, ttl, skip_hours, skip_days, update_period, update_frequency, consecutive_failures, last_error, last_success_at, disabled, link, description, claimed_until
 `
 
 type CreateFeedParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    uuid.UUID
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	Url         string
+	UserID      uuid.UUID
+	Link        sql.NullString
+	Description sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -42,6 +117,8 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.Link,
+		arg.Description,
 	)
 	var i Feed
 	err := row.Scan(
@@ -52,18 +129,163 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.UpdatePeriod,
+		&i.UpdateFrequency,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.Disabled,
+		&i.Link,
+		&i.Description,
+		&i.ClaimedUntil,
+	)
+	return i, err
+}
+
+const enableFeed = `-- name: EnableFeed :one
+UPDATE feeds
+SET disabled = false, consecutive_failures = 0, next_fetch_at = NULL, updated_at = now()
+WHERE url = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval, next_fetch_at, ttl, skip_hours, skip_days, update_period, update_frequency, consecutive_failures, last_error, last_success_at, disabled, link, description, claimed_until
+`
+
+func (q *Queries) EnableFeed(ctx context.Context, url string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, enableFeed, url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.UpdatePeriod,
+		&i.UpdateFrequency,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.Disabled,
+		&i.Link,
+		&i.Description,
+		&i.ClaimedUntil,
+	)
+	return i, err
+}
+
+const getFailingFeeds = `-- name: GetFailingFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval, next_fetch_at, ttl, skip_hours, skip_days, update_period, update_frequency, consecutive_failures, last_error, last_success_at, disabled, link, description, claimed_until FROM feeds
+WHERE disabled OR consecutive_failures > 0
+ORDER BY disabled DESC, consecutive_failures DESC
+`
+
+func (q *Queries) GetFailingFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFailingFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.FetchInterval,
+			&i.NextFetchAt,
+			&i.Ttl,
+			pq.Array(&i.SkipHours),
+			pq.Array(&i.SkipDays),
+			&i.UpdatePeriod,
+			&i.UpdateFrequency,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.Disabled,
+			&i.Link,
+			&i.Description,
+			&i.ClaimedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval, next_fetch_at, ttl, skip_hours, skip_days, update_period, update_frequency, consecutive_failures, last_error, last_success_at, disabled, link, description, claimed_until FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.UpdatePeriod,
+		&i.UpdateFrequency,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.Disabled,
+		&i.Link,
+		&i.Description,
+		&i.ClaimedUntil,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT name, url, user_id FROM feeds
+SELECT name, url, user_id, link, description FROM feeds
 `
 
 type GetFeedsRow struct {
-	Name   string
-	Url    string
-	UserID uuid.UUID
+	Name        string
+	Url         string
+	UserID      uuid.UUID
+	Link        sql.NullString
+	Description sql.NullString
 }
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
@@ -75,7 +297,13 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	var items []GetFeedsRow
 	for rows.Next() {
 		var i GetFeedsRow
-		if err := rows.Scan(&i.Name, &i.Url, &i.UserID); err != nil {
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.Link,
+			&i.Description,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -89,14 +317,63 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1
+const recordFeedFailure = `-- name: RecordFeedFailure :one
+UPDATE feeds
+SET consecutive_failures = consecutive_failures + 1,
+    last_error = $1,
+    disabled = consecutive_failures + 1 >= $2::integer,
+    claimed_until = NULL,
+    updated_at = now()
+WHERE id = $3
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval, next_fetch_at, ttl, skip_hours, skip_days, update_period, update_frequency, consecutive_failures, last_error, last_success_at, disabled, link, description, claimed_until
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
+type RecordFeedFailureParams struct {
+	LastError   sql.NullString
+	MaxFailures int32
+	ID          uuid.UUID
+}
+
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, recordFeedFailure, arg.LastError, arg.MaxFailures, arg.ID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.UpdatePeriod,
+		&i.UpdateFrequency,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.Disabled,
+		&i.Link,
+		&i.Description,
+		&i.ClaimedUntil,
+	)
+	return i, err
+}
+
+const recordFeedSuccess = `-- name: RecordFeedSuccess :one
+UPDATE feeds
+SET consecutive_failures = 0, last_error = NULL, last_success_at = now(), updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval, next_fetch_at, ttl, skip_hours, skip_days, update_period, update_frequency, consecutive_failures, last_error, last_success_at, disabled, link, description, claimed_until
+`
+
+func (q *Queries) RecordFeedSuccess(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, recordFeedSuccess, id)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -106,17 +383,173 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.UpdatePeriod,
+		&i.UpdateFrequency,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.Disabled,
+		&i.Link,
+		&i.Description,
+		&i.ClaimedUntil,
 	)
 	return i, err
 }
 
-const markFeedFetched = `-- name: MarkFeedFetched :exec
+const setFeedFetchInterval = `-- name: SetFeedFetchInterval :one
+UPDATE feeds
+SET fetch_interval = $1,
+    next_fetch_at = last_fetched_at + $1 * interval '1 second',
+    updated_at = now()
+WHERE url = $2
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval, next_fetch_at, ttl, skip_hours, skip_days, update_period, update_frequency, consecutive_failures, last_error, last_success_at, disabled, link, description, claimed_until
+`
+
+type SetFeedFetchIntervalParams struct {
+	FetchInterval sql.NullInt32
+	Url           string
+}
+
+func (q *Queries) SetFeedFetchInterval(ctx context.Context, arg SetFeedFetchIntervalParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, setFeedFetchInterval, arg.FetchInterval, arg.Url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.UpdatePeriod,
+		&i.UpdateFrequency,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.Disabled,
+		&i.Link,
+		&i.Description,
+		&i.ClaimedUntil,
+	)
+	return i, err
+}
+
+const setFeedNextFetch = `-- name: SetFeedNextFetch :exec
+UPDATE feeds
+SET next_fetch_at = now() + $1::integer * interval '1 second', claimed_until = NULL, updated_at = now()
+WHERE id = $2
+`
+
+type SetFeedNextFetchParams struct {
+	DelaySeconds int32
+	ID           uuid.UUID
+}
+
+func (q *Queries) SetFeedNextFetch(ctx context.Context, arg SetFeedNextFetchParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedNextFetch, arg.DelaySeconds, arg.ID)
+	return err
+}
+
+const updateFeedCacheHeaders = `-- name: UpdateFeedCacheHeaders :exec
 UPDATE feeds
-SET last_fetched_at = now(), updated_at = now()
+SET etag = $2, last_modified = $3, updated_at = now()
 WHERE id = $1
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
+type UpdateFeedCacheHeadersParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) UpdateFeedCacheHeaders(ctx context.Context, arg UpdateFeedCacheHeadersParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCacheHeaders, arg.ID, arg.Etag, arg.LastModified)
 	return err
 }
+
+const updateFeedDetails = `-- name: UpdateFeedDetails :exec
+UPDATE feeds
+SET link = COALESCE($1, link),
+    description = COALESCE($2, description),
+    updated_at = now()
+WHERE id = $3
+`
+
+type UpdateFeedDetailsParams struct {
+	Link        sql.NullString
+	Description sql.NullString
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdateFeedDetails(ctx context.Context, arg UpdateFeedDetailsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedDetails, arg.Link, arg.Description, arg.ID)
+	return err
+}
+
+const updateFeedPublishHints = `-- name: UpdateFeedPublishHints :one
+UPDATE feeds
+SET ttl = $2, skip_hours = $3, skip_days = $4, update_period = $5, update_frequency = $6, updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval, next_fetch_at, ttl, skip_hours, skip_days, update_period, update_frequency, consecutive_failures, last_error, last_success_at, disabled, link, description, claimed_until
+`
+
+type UpdateFeedPublishHintsParams struct {
+	ID              uuid.UUID
+	Ttl             sql.NullInt32
+	SkipHours       []int32
+	SkipDays        []string
+	UpdatePeriod    sql.NullString
+	UpdateFrequency sql.NullInt32
+}
+
+func (q *Queries) UpdateFeedPublishHints(ctx context.Context, arg UpdateFeedPublishHintsParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, updateFeedPublishHints,
+		arg.ID,
+		arg.Ttl,
+		pq.Array(arg.SkipHours),
+		pq.Array(arg.SkipDays),
+		arg.UpdatePeriod,
+		arg.UpdateFrequency,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchInterval,
+		&i.NextFetchAt,
+		&i.Ttl,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.UpdatePeriod,
+		&i.UpdateFrequency,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.Disabled,
+		&i.Link,
+		&i.Description,
+		&i.ClaimedUntil,
+	)
+	return i, err
+}
@@ -12,13 +12,29 @@ import (
 )
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Name                string
+	Url                 string
+	UserID              uuid.UUID
+	LastFetchedAt       sql.NullTime
+	Etag                sql.NullString
+	LastModified        sql.NullString
+	FetchInterval       sql.NullInt32
+	NextFetchAt         sql.NullTime
+	Ttl                 sql.NullInt32
+	SkipHours           []int32
+	SkipDays            []string
+	UpdatePeriod        sql.NullString
+	UpdateFrequency     sql.NullInt32
+	ConsecutiveFailures int32
+	LastError           sql.NullString
+	LastSuccessAt       sql.NullTime
+	Disabled            bool
+	Link                sql.NullString
+	Description         sql.NullString
+	ClaimedUntil        sql.NullTime
 }
 
 type FeedFollow struct {
@@ -27,17 +43,83 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Folder    sql.NullString
+}
+
+type FetchAttempt struct {
+	ID           uuid.UUID
+	FeedID       uuid.UUID
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	HttpStatus   sql.NullInt32
+	Bytes        sql.NullInt64
+	ItemsSeen    int32
+	PostsAdded   int32
+	PostsSkipped int32
+	Error        sql.NullString
+	PostsUpdated int32
 }
 
 type Post struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Title         sql.NullString
+	Url           string
+	Description   sql.NullString
+	PublishedAt   time.Time
+	FeedID        uuid.UUID
+	DateEstimated bool
+	Guid          string
+	ContentHash   string
+	ShortID       string
+	SearchVector  interface{}
+	Content       sql.NullString
+	Author        sql.NullString
+	CommentsUrl   sql.NullString
+}
+
+type PostCategory struct {
+	PostID uuid.UUID
+	Name   string
+}
+
+type PostEnclosure struct {
+	PostID   uuid.UUID
+	Url      string
+	Length   sql.NullInt64
+	MimeType sql.NullString
+	Duration sql.NullInt32
+	Episode  sql.NullInt32
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostRevision struct {
 	ID          uuid.UUID
 	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	PostID      uuid.UUID
 	Title       sql.NullString
 	Url         string
 	Description sql.NullString
-	PublishedAt time.Time
-	FeedID      uuid.UUID
+	ContentHash string
+	Content     sql.NullString
+}
+
+type PostStar struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type PostView struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	SeenAt time.Time
 }
 
 type User struct {
@@ -11,10 +11,11 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, date_estimated, guid, content_hash, content, author, comments_url, short_id)
 VALUES (
     $1,
     $2,
@@ -23,20 +24,34 @@ VALUES (
     $5,
     $6,
     $7,
-    $8
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15
 )
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, date_estimated, guid, content_hash, short_i_d, search_vector, content, author, comments_url
 `
 
 type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       sql.NullString
-	Url         string
-	Description sql.NullString
-	PublishedAt time.Time
-	FeedID      uuid.UUID
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Title         sql.NullString
+	Url           string
+	Description   sql.NullString
+	PublishedAt   time.Time
+	FeedID        uuid.UUID
+	DateEstimated bool
+	Guid          string
+	ContentHash   string
+	Content       sql.NullString
+	Author        sql.NullString
+	CommentsUrl   sql.NullString
+	ShortID       string
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -49,6 +64,13 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.DateEstimated,
+		arg.Guid,
+		arg.ContentHash,
+		arg.Content,
+		arg.Author,
+		arg.CommentsUrl,
+		arg.ShortID,
 	)
 	var i Post
 	err := row.Scan(
@@ -60,42 +82,242 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.DateEstimated,
+		&i.Guid,
+		&i.ContentHash,
+		&i.ShortID,
+		&i.SearchVector,
+		&i.Content,
+		&i.Author,
+		&i.CommentsUrl,
+	)
+	return i, err
+}
+
+const getLegacyPostByUrl = `-- name: GetLegacyPostByUrl :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, date_estimated, guid, content_hash, short_i_d, search_vector, content, author, comments_url FROM posts
+WHERE feed_id = $1
+AND url = $2
+AND guid = url
+`
+
+type GetLegacyPostByUrlParams struct {
+	FeedID uuid.UUID
+	Url    string
+}
+
+func (q *Queries) GetLegacyPostByUrl(ctx context.Context, arg GetLegacyPostByUrlParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getLegacyPostByUrl, arg.FeedID, arg.Url)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.DateEstimated,
+		&i.Guid,
+		&i.ContentHash,
+		&i.ShortID,
+		&i.SearchVector,
+		&i.Content,
+		&i.Author,
+		&i.CommentsUrl,
+	)
+	return i, err
+}
+
+const getPost = `-- name: GetPost :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, date_estimated, guid, content_hash, short_i_d, search_vector, content, author, comments_url FROM posts
+WHERE id = $1
+`
+
+func (q *Queries) GetPost(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPost, id)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.DateEstimated,
+		&i.Guid,
+		&i.ContentHash,
+		&i.ShortID,
+		&i.SearchVector,
+		&i.Content,
+		&i.Author,
+		&i.CommentsUrl,
+	)
+	return i, err
+}
+
+const getPostByGuid = `-- name: GetPostByGuid :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, date_estimated, guid, content_hash, short_i_d, search_vector, content, author, comments_url FROM posts
+WHERE feed_id = $1
+AND guid = $2
+`
+
+type GetPostByGuidParams struct {
+	FeedID uuid.UUID
+	Guid   string
+}
+
+func (q *Queries) GetPostByGuid(ctx context.Context, arg GetPostByGuidParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByGuid, arg.FeedID, arg.Guid)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.DateEstimated,
+		&i.Guid,
+		&i.ContentHash,
+		&i.ShortID,
+		&i.SearchVector,
+		&i.Content,
+		&i.Author,
+		&i.CommentsUrl,
+	)
+	return i, err
+}
+
+const getPostByShortID = `-- name: GetPostByShortID :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, date_estimated, guid, content_hash, short_i_d, search_vector, content, author, comments_url FROM posts
+WHERE short_id = $1
+`
+
+func (q *Queries) GetPostByShortID(ctx context.Context, shortID string) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByShortID, shortID)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.DateEstimated,
+		&i.Guid,
+		&i.ContentHash,
+		&i.ShortID,
+		&i.SearchVector,
+		&i.Content,
+		&i.Author,
+		&i.CommentsUrl,
 	)
 	return i, err
 }
 
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, title, url, description, published_at, posts.feed_id, feed_follows.id, feed_follows.created_at, feed_follows.updated_at, user_id, feed_follows.feed_id FROM posts
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.date_estimated, posts.guid, posts.content_hash, posts.short_i_d, posts.search_vector, posts.content, posts.author, posts.comments_url, EXISTS (
+    SELECT 1 FROM post_revisions
+    WHERE post_revisions.post_id = posts.id
+    AND post_revisions.created_at > post_views.seen_at  -- never shown posts have nothing to be updated from
+) AS updated_since_seen, feeds.name AS feed_name
+FROM posts
 INNER JOIN feed_follows
 ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds
+ON posts.feed_id = feeds.id
+LEFT JOIN post_reads
+ON post_reads.post_id = posts.id
+AND post_reads.user_id = feed_follows.user_id
+LEFT JOIN post_views
+ON post_views.post_id = posts.id
+AND post_views.user_id = feed_follows.user_id
 WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC NULLS LAST
-LIMIT $2
+AND (NOT $2::boolean OR post_reads.post_id IS NULL)
+AND (COALESCE(cardinality($3::text[]), 0) = 0 OR feeds.url = ANY($3::text[]) OR feeds.name = ANY($3::text[]))
+AND ($4::timestamp IS NULL OR posts.published_at >= $4::timestamp)
+AND ($5::timestamp IS NULL OR posts.published_at < $5::timestamp)
+AND ($6::text IS NULL OR posts.title ILIKE '%' || $6::text || '%' OR posts.description ILIKE '%' || $6::text || '%')
+AND ($7::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM posts AS cursor_post
+    INNER JOIN feeds AS cursor_feed
+    ON cursor_post.feed_id = cursor_feed.id
+    WHERE cursor_post.id = $7::uuid
+    AND CASE $8::text
+        WHEN 'oldest' THEN (posts.published_at, posts.id) > (cursor_post.published_at, cursor_post.id)
+        WHEN 'created' THEN (posts.created_at, posts.published_at, posts.id) < (cursor_post.created_at, cursor_post.published_at, cursor_post.id)
+        WHEN 'feed' THEN (feeds.name, feeds.id) > (cursor_feed.name, cursor_feed.id)
+            OR ((feeds.name, feeds.id) = (cursor_feed.name, cursor_feed.id) AND (posts.published_at, posts.id) < (cursor_post.published_at, cursor_post.id))
+        ELSE (posts.published_at, posts.id) < (cursor_post.published_at, cursor_post.id)
+    END
+))
+ORDER BY
+    CASE WHEN $8::text = 'feed' THEN feeds.name END,
+    CASE WHEN $8::text = 'feed' THEN feeds.id END,
+    CASE WHEN $8::text = 'created' THEN posts.created_at END DESC,
+    CASE WHEN $8::text = 'oldest' THEN posts.published_at END,
+    CASE WHEN $8::text = 'oldest' THEN posts.id END,
+    posts.published_at DESC,
+    posts.id DESC
+LIMIT $9
+OFFSET $10
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID     uuid.UUID
+	UnreadOnly bool
+	Feeds      []string
+	Since      sql.NullTime
+	Until      sql.NullTime
+	Keyword    sql.NullString
+	BeforeID   uuid.NullUUID
+	Sort       string
+	Limit      int32
+	Offset     int32
 }
 
 type GetPostsForUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       sql.NullString
-	Url         string
-	Description sql.NullString
-	PublishedAt time.Time
-	FeedID      uuid.UUID
-	ID_2        uuid.UUID
-	CreatedAt_2 time.Time
-	UpdatedAt_2 time.Time
-	UserID      uuid.UUID
-	FeedID_2    uuid.UUID
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Title            sql.NullString
+	Url              string
+	Description      sql.NullString
+	PublishedAt      time.Time
+	FeedID           uuid.UUID
+	DateEstimated    bool
+	Guid             string
+	ContentHash      string
+	ShortID          string
+	SearchVector     interface{}
+	Content          sql.NullString
+	Author           sql.NullString
+	CommentsUrl      sql.NullString
+	UpdatedSinceSeen bool
+	FeedName         string
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.UnreadOnly,
+		pq.Array(arg.Feeds),
+		arg.Since,
+		arg.Until,
+		arg.Keyword,
+		arg.BeforeID,
+		arg.Sort,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -112,11 +334,16 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
-			&i.ID_2,
-			&i.CreatedAt_2,
-			&i.UpdatedAt_2,
-			&i.UserID,
-			&i.FeedID_2,
+			&i.DateEstimated,
+			&i.Guid,
+			&i.ContentHash,
+			&i.ShortID,
+			&i.SearchVector,
+			&i.Content,
+			&i.Author,
+			&i.CommentsUrl,
+			&i.UpdatedSinceSeen,
+			&i.FeedName,
 		); err != nil {
 			return nil, err
 		}
@@ -130,3 +357,181 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const searchPosts = `-- name: SearchPosts :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.date_estimated, posts.guid, posts.content_hash, posts.short_i_d, posts.search_vector, posts.content, posts.author, posts.comments_url, feeds.name AS feed_name, ts_rank(posts.search_vector, websearch_to_tsquery('english', $1)) AS rank
+FROM posts
+INNER JOIN feeds
+ON posts.feed_id = feeds.id
+WHERE posts.search_vector @@ websearch_to_tsquery('english', $1)
+AND ($2::boolean OR posts.feed_id IN (
+    SELECT feed_id FROM feed_follows
+    WHERE user_id = $3
+))
+ORDER BY rank DESC, posts.published_at DESC
+LIMIT $4
+`
+
+type SearchPostsParams struct {
+	Query    string
+	AllFeeds bool
+	UserID   uuid.UUID
+	Limit    int32
+}
+
+type SearchPostsRow struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Title         sql.NullString
+	Url           string
+	Description   sql.NullString
+	PublishedAt   time.Time
+	FeedID        uuid.UUID
+	DateEstimated bool
+	Guid          string
+	ContentHash   string
+	ShortID       string
+	SearchVector  interface{}
+	Content       sql.NullString
+	Author        sql.NullString
+	CommentsUrl   sql.NullString
+	FeedName      string
+	Rank          float32
+}
+
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts,
+		arg.Query,
+		arg.AllFeeds,
+		arg.UserID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsRow
+	for rows.Next() {
+		var i SearchPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.DateEstimated,
+			&i.Guid,
+			&i.ContentHash,
+			&i.ShortID,
+			&i.SearchVector,
+			&i.Content,
+			&i.Author,
+			&i.CommentsUrl,
+			&i.FeedName,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setPostGuid = `-- name: SetPostGuid :one
+UPDATE posts
+SET guid = $2, updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, date_estimated, guid, content_hash, short_i_d, search_vector, content, author, comments_url
+`
+
+type SetPostGuidParams struct {
+	ID   uuid.UUID
+	Guid string
+}
+
+func (q *Queries) SetPostGuid(ctx context.Context, arg SetPostGuidParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, setPostGuid, arg.ID, arg.Guid)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.DateEstimated,
+		&i.Guid,
+		&i.ContentHash,
+		&i.ShortID,
+		&i.SearchVector,
+		&i.Content,
+		&i.Author,
+		&i.CommentsUrl,
+	)
+	return i, err
+}
+
+const updatePost = `-- name: UpdatePost :one
+UPDATE posts
+SET updated_at = $2, title = $3, url = $4, description = $5, content_hash = $6, content = $7, author = $8, comments_url = $9
+WHERE id = $1
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, date_estimated, guid, content_hash, short_i_d, search_vector, content, author, comments_url
+`
+
+type UpdatePostParams struct {
+	ID          uuid.UUID
+	UpdatedAt   time.Time
+	Title       sql.NullString
+	Url         string
+	Description sql.NullString
+	ContentHash string
+	Content     sql.NullString
+	Author      sql.NullString
+	CommentsUrl sql.NullString
+}
+
+func (q *Queries) UpdatePost(ctx context.Context, arg UpdatePostParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, updatePost,
+		arg.ID,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.ContentHash,
+		arg.Content,
+		arg.Author,
+		arg.CommentsUrl,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.DateEstimated,
+		&i.Guid,
+		&i.ContentHash,
+		&i.ShortID,
+		&i.SearchVector,
+		&i.Content,
+		&i.Author,
+		&i.CommentsUrl,
+	)
+	return i, err
+}
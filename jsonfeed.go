@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+type JSONFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	Description string           `json:"description"`
+	Authors     []JSONFeedAuthor `json:"authors"`
+	Author      *JSONFeedAuthor  `json:"author"` //JSON Feed 1.0, replaced by authors in 1.1
+	Items       []JSONFeedItem   `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            jsonFeedID           `json:"id"`
+	URL           string               `json:"url"`
+	ExternalURL   string               `json:"external_url"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	Summary       string               `json:"summary"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Authors       []JSONFeedAuthor     `json:"authors"`
+	Author        *JSONFeedAuthor      `json:"author"`
+	Tags          []string             `json:"tags"`
+	Attachments   []JSONFeedAttachment `json:"attachments"`
+}
+
+type JSONFeedAttachment struct {
+	URL               string  `json:"url"`
+	MimeType          string  `json:"mime_type"`
+	SizeInBytes       float64 `json:"size_in_bytes"`
+	DurationInSeconds float64 `json:"duration_in_seconds"`
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedID string //Item ids should be strings, but some publishers send numbers
+
+func (id *jsonFeedID) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*id = jsonFeedID(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("invalid item id %s", string(data))
+	}
+	*id = jsonFeedID(number.String())
+	return nil
+}
+
+func isJSONFeed(data []byte, contentType string) bool { //Checks whether a response holds a JSON Feed, by content type or by body shape
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/feed+json" || mediaType == "application/json" {
+		return true
+	}
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+func parseJSONFeed(data []byte) (*parsedFeed, error) { //Decodes a JSON Feed 1.0/1.1 document and maps it into the shared parsedFeed format
+	var jsonFeed JSONFeed
+	if err := json.Unmarshal(data, &jsonFeed); err != nil {
+		return nil, fmt.Errorf("error decoding json feed data: %w", err)
+	}
+	if !strings.Contains(jsonFeed.Version, "jsonfeed.org/version/") {
+		return nil, fmt.Errorf("unsupported json feed version: %q", jsonFeed.Version)
+	}
+
+	var feed parsedFeed
+	feed.Title = jsonFeed.Title
+	feed.Link = jsonFeed.HomePageURL
+	feed.Description = jsonFeed.Description
+
+	for _, entry := range jsonFeed.Items {
+		item := feedItem{
+			Title:       entry.Title,
+			Link:        entry.URL,
+			Description: entry.ContentHTML,
+			PubDate:     entry.DatePublished,
+			GUID:        string(entry.ID),
+			Content:     entry.ContentHTML,
+			Author:      authorNames(entry.Authors, entry.Author),
+			Categories:  entry.Tags,
+		}
+		if item.Content == "" {
+			item.Content = entry.ContentText
+		}
+		for _, attachment := range entry.Attachments {
+			enclosure := feedEnclosure{URL: attachment.URL, Type: attachment.MimeType}
+			if attachment.SizeInBytes > 0 {
+				enclosure.Length = strconv.FormatInt(int64(attachment.SizeInBytes), 10)
+			}
+			if attachment.DurationInSeconds > 0 {
+				enclosure.Duration = strconv.FormatInt(int64(attachment.DurationInSeconds), 10)
+			}
+			item.Enclosures = append(item.Enclosures, enclosure)
+		}
+		if item.Author == "" { //Items inherit the feed's authors
+			item.Author = authorNames(jsonFeed.Authors, jsonFeed.Author)
+		}
+		if item.Description == "" {
+			item.Description = entry.ContentText
+		}
+		if item.Description == "" {
+			item.Description = entry.Summary
+		}
+		if item.Link == "" {
+			item.Link = entry.ExternalURL
+		}
+		if item.PubDate == "" {
+			item.PubDate = entry.DateModified
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return &feed, nil
+}
+
+func authorNames(authors []JSONFeedAuthor, author *JSONFeedAuthor) string { //Joins author names, from the 1.1 authors list or the 1.0 single author
+	if len(authors) == 0 && author != nil {
+		authors = []JSONFeedAuthor{*author}
+	}
+	var names []string
+	for _, a := range authors {
+		if name := strings.TrimSpace(a.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
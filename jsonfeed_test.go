@@ -0,0 +1,96 @@
+package main
+
+import "testing"
+
+func TestParseJSONFeed(t *testing.T) {
+	feed, err := parseJSONFeed(readTestdata(t, "feed.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if feed.Link != "https://example.com/" || feed.Description != "Short posts" {
+		t.Errorf("channel = link %q, description %q", feed.Link, feed.Description)
+	}
+	if len(feed.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(feed.Items))
+	}
+
+	tests := []struct {
+		name                             string
+		item                             feedItem
+		guid, link, description, pubDate string
+		author, body                     string
+	}{
+		{
+			name:        "html item",
+			item:        feed.Items[0],
+			guid:        "1",
+			link:        "https://example.com/posts/1",
+			description: "<p>Hello world</p>",
+			pubDate:     "2024-05-06T10:00:00Z",
+			author:      "Jane Doe",
+			body:        "<p>Hello world</p>",
+		},
+		{
+			name:        "numeric id and fallbacks",
+			item:        feed.Items[1],
+			guid:        "2",
+			link:        "https://elsewhere.example.org/",
+			description: "Plain text only",
+			pubDate:     "2024-05-07T10:00:00Z",
+			author:      "John Roe",
+			body:        "Plain text only",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := []struct {
+				field     string
+				got, want string
+			}{
+				{"guid", tt.item.GUID, tt.guid},
+				{"link", tt.item.Link, tt.link},
+				{"description", tt.item.Description, tt.description},
+				{"pubDate", tt.item.PubDate, tt.pubDate},
+				{"author", tt.item.Author, tt.author},
+				{"content", tt.item.Content, tt.body},
+			}
+			for _, f := range fields {
+				if f.got != f.want {
+					t.Errorf("%s = %q, want %q", f.field, f.got, f.want)
+				}
+			}
+		})
+	}
+
+	first := feed.Items[0]
+	if len(first.Categories) != 1 || first.Categories[0] != "greetings" {
+		t.Errorf("categories = %v, want [greetings]", first.Categories)
+	}
+	wantEnclosure := feedEnclosure{URL: "https://example.com/posts/1.mp3", Length: "2048", Type: "audio/mpeg", Duration: "95"}
+	if len(first.Enclosures) != 1 || first.Enclosures[0] != wantEnclosure {
+		t.Errorf("enclosures = %+v, want [%+v]", first.Enclosures, wantEnclosure)
+	}
+}
+
+func TestParseJSONFeedVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "1.0", data: `{"version": "https://jsonfeed.org/version/1", "title": "Old", "items": []}`},
+		{name: "1.1", data: `{"version": "https://jsonfeed.org/version/1.1", "title": "New", "items": []}`},
+		{name: "missing version", data: `{"title": "Plain json", "items": []}`, wantErr: true},
+		{name: "not json", data: `{"title": `, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseJSONFeed([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -37,11 +37,25 @@ func main() {
 	commands.register("users", handlerUsers)	//Users command	- lists users in database
 	commands.register("agg", handlerAgg)	//Aggregator command - handles long-running aggregator service - input a time duration
 	commands.register("addfeed", middlewareLoggedIn(handlerAddFeed))	//Addfeed command - adds a feed to database
-	commands.register("feeds", handlerFeeds)	//Feeds command - lists feeds in database
+	commands.register("import-opml", middlewareLoggedIn(handlerImportOPML))	//Import-opml command - adds and follows every feed in an OPML file
+	commands.register("export-opml", middlewareLoggedIn(handlerExportOPML))	//Export-opml command - writes followed feeds as an OPML file
+	commands.register("feeds", handlerFeeds)	//Feeds command - lists feeds in database, or failing feeds with --failing
 	commands.register("follow", middlewareLoggedIn(handlerFollow))	//Follow command - adds a follow record, for the given url feed and current user
 	commands.register("following", middlewareLoggedIn(handlerFollowing))	//Following command - lists all feeds being followed by current user
 	commands.register("unfollow", middlewareLoggedIn(handlerUnfollow))	//Unfollows a feed for current user
-	commands.register("browse", middlewareLoggedIn(handlerBrowse))
+	commands.register("browse", middlewareLoggedIn(handlerBrowse))	//Browse command - lists posts from followed feeds, optionally only unread ones
+	commands.register("show", handlerShow)	//Show command - shows a post in full, with author, categories and content
+	commands.register("read", middlewareLoggedIn(handlerRead))	//Read command - marks a post as read for current user
+	commands.register("unread", middlewareLoggedIn(handlerUnread))	//Unread command - marks a post as unread for current user
+	commands.register("markallread", middlewareLoggedIn(handlerMarkAllRead))	//Markallread command - marks all posts, or all posts of a feed url, as read
+	commands.register("star", middlewareLoggedIn(handlerStar))	//Star command - stars a post, keeping it after its feed is unfollowed
+	commands.register("unstar", middlewareLoggedIn(handlerUnstar))	//Unstar command - removes a star from a post
+	commands.register("starred", middlewareLoggedIn(handlerStarred))	//Starred command - lists starred posts
+	commands.register("search", middlewareLoggedIn(handlerSearch))	//Search command - full-text searches posts from followed feeds, or all feeds with --all
+	commands.register("setinterval", handlerSetInterval)	//Setinterval command - sets how often a feed is fetched by agg
+	commands.register("enablefeed", handlerEnableFeed)	//Enablefeed command - re-enables a feed disabled after repeated failures
+	commands.register("fetchlog", handlerFetchLog)	//Fetchlog command - shows recent fetch attempts, optionally for one feed url
+	commands.register("enclosures", handlerEnclosures)	//Enclosures command - lists the media files of a feed url's posts
 
 	args := os.Args	//Gets user input arguments
 	if len(args) < 2 {
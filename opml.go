@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    struct {
+		Title       string `xml:"title"`
+		DateCreated string `xml:"dateCreated,omitempty"`
+	} `xml:"head"`
+	Body struct {
+		Outlines []OPMLOutline `xml:"outline"`
+	} `xml:"body"`
+}
+
+type OPMLOutline struct { //A feed when it has an xmlUrl, otherwise a folder holding nested outlines
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Outlines []OPMLOutline `xml:"outline"`
+}
+
+type opmlFeed struct { //A subscription in an OPML file, with the path of folders it sits in
+	Name    string
+	URL     string
+	SiteURL string
+	Folder  string
+}
+
+const folderSeparator = "/"
+
+func parseOPML(data []byte) ([]opmlFeed, error) { //Decodes an OPML document and flattens its outlines into a list of subscriptions
+	var opml OPML
+	if err := xml.Unmarshal(data, &opml); err != nil {
+		return nil, fmt.Errorf("error decoding opml data: %w", err)
+	}
+	return opmlFeeds(opml.Body.Outlines, ""), nil
+}
+
+func opmlFeeds(outlines []OPMLOutline, folder string) []opmlFeed { //Walks nested outlines, outlines without an xmlUrl are treated as folders
+	var feeds []opmlFeed
+	for _, outline := range outlines {
+		name := strings.TrimSpace(outline.Title)
+		if name == "" {
+			name = strings.TrimSpace(outline.Text)
+		}
+
+		if url := strings.TrimSpace(outline.XMLURL); url != "" {
+			if name == "" {
+				name = url
+			}
+			feeds = append(feeds, opmlFeed{
+				Name:    name,
+				URL:     url,
+				SiteURL: strings.TrimSpace(outline.HTMLURL),
+				Folder:  folder,
+			})
+		}
+
+		if len(outline.Outlines) > 0 {
+			subfolder := folder
+			if name != "" && outline.XMLURL == "" {
+				subfolder = strings.Trim(folder+folderSeparator+name, folderSeparator)
+			}
+			feeds = append(feeds, opmlFeeds(outline.Outlines, subfolder)...)
+		}
+	}
+	return feeds
+}
+
+func buildOPML(title string, feeds []opmlFeed) ([]byte, error) { //Encodes subscriptions as an OPML 2.0 document, with folders as nested outlines
+	opml := OPML{Version: "2.0"}
+	opml.Head.Title = title
+	opml.Head.DateCreated = time.Now().UTC().Format(time.RFC1123Z)
+
+	for _, feed := range feeds {
+		outline := OPMLOutline{
+			Text:    feed.Name,
+			Title:   feed.Name,
+			Type:    "rss",
+			XMLURL:  feed.URL,
+			HTMLURL: feed.SiteURL,
+		}
+		var path []string
+		if feed.Folder != "" {
+			path = strings.Split(feed.Folder, folderSeparator)
+		}
+		addOutline(&opml.Body.Outlines, path, outline)
+	}
+
+	data, err := xml.MarshalIndent(opml, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding opml data: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+func addOutline(outlines *[]OPMLOutline, path []string, outline OPMLOutline) { //Adds an outline under the given folder path, creating folder outlines as needed
+	if len(path) == 0 {
+		*outlines = append(*outlines, outline)
+		return
+	}
+	for i := range *outlines {
+		if folder := &(*outlines)[i]; folder.XMLURL == "" && folder.Text == path[0] {
+			addOutline(&folder.Outlines, path[1:], outline)
+			return
+		}
+	}
+	*outlines = append(*outlines, OPMLOutline{Text: path[0]})
+	addOutline(&(*outlines)[len(*outlines)-1].Outlines, path[1:], outline)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseOPML(t *testing.T) {
+	feeds, err := parseOPML(readTestdata(t, "subscriptions.opml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []opmlFeed{
+		{Name: "Example Blog", URL: "https://example.com/feed.atom", SiteURL: "https://example.com/"},
+		{Name: "Example Journal", URL: "https://example.com/index.rdf", Folder: "Tech"},
+		{Name: "https://example.com/podcast.xml", URL: "https://example.com/podcast.xml", Folder: "Tech/Podcasts"},
+	}
+	if len(feeds) != len(want) {
+		t.Fatalf("got %d feeds, want %d: %+v", len(feeds), len(want), feeds)
+	}
+	for i := range want {
+		if feeds[i] != want[i] {
+			t.Errorf("feed %d = %+v, want %+v", i, feeds[i], want[i])
+		}
+	}
+
+	if _, err := parseOPML([]byte("<opml><body>")); err == nil {
+		t.Error("expected an error for a truncated document")
+	}
+}
+
+func TestOPMLRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		feeds []opmlFeed
+	}{
+		{
+			name: "flat",
+			feeds: []opmlFeed{
+				{Name: "Example Blog", URL: "https://example.com/feed.atom", SiteURL: "https://example.com/"},
+				{Name: "Ampersand & <brackets>", URL: "https://example.com/feed?a=1&b=2"},
+			},
+		},
+		{
+			name: "nested folders",
+			feeds: []opmlFeed{
+				{Name: "Top", URL: "https://example.com/top.xml"},
+				{Name: "Journal", URL: "https://example.com/index.rdf", Folder: "Tech"},
+				{Name: "Podcast", URL: "https://example.com/podcast.xml", Folder: "Tech/Podcasts"},
+				{Name: "Second in folder", URL: "https://example.com/second.xml", Folder: "Tech"},
+				{Name: "News", URL: "https://news.example.org/rss", Folder: "News"},
+			},
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := buildOPML("gator subscriptions", tt.feeds)
+			if err != nil {
+				t.Fatalf("error building opml: %v", err)
+			}
+			if !strings.HasPrefix(string(data), "<?xml") {
+				t.Errorf("document has no xml header: %.40q", data)
+			}
+
+			feeds, err := parseOPML(data)
+			if err != nil {
+				t.Fatalf("error parsing built opml: %v", err)
+			}
+			if len(feeds) != len(tt.feeds) {
+				t.Fatalf("got %d feeds back, want %d: %+v", len(feeds), len(tt.feeds), feeds)
+			}
+			found := make(map[opmlFeed]bool) //Feeds are grouped by folder when built, so order may change
+			for _, feed := range feeds {
+				found[feed] = true
+			}
+			for _, feed := range tt.feeds {
+				if !found[feed] {
+					t.Errorf("feed %+v lost in round trip, got %+v", feed, feeds)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+type RDFFeed struct { //RSS 1.0 document, items sit beside the channel rather than inside it
+	Channel struct {
+		Title           string `xml:"title"`
+		Link            string `xml:"link"`
+		Description     string `xml:"description"`
+		Date            string `xml:"http://purl.org/dc/elements/1.1/ date"`
+		UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	} `xml:"channel"`
+	Items []RDFItem `xml:"item"`
+}
+
+type RDFItem struct {
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+}
+
+func parseRDF(data []byte) (*parsedFeed, error) { //Decodes an RSS 1.0/RDF document and maps it into the shared parsedFeed format
+	var rdf RDFFeed
+	if err := xml.Unmarshal(data, &rdf); err != nil {
+		return nil, fmt.Errorf("error decoding rdf data: %w", err)
+	}
+
+	var feed parsedFeed
+	feed.Title = rdf.Channel.Title
+	feed.Link = strings.TrimSpace(rdf.Channel.Link)
+	feed.Description = rdf.Channel.Description
+	feed.PubDate = strings.TrimSpace(rdf.Channel.Date)
+	feed.UpdatePeriod = rdf.Channel.UpdatePeriod
+	feed.UpdateFrequency = rdf.Channel.UpdateFrequency
+
+	for _, entry := range rdf.Items {
+		item := feedItem{
+			Title:       entry.Title,
+			Link:        strings.TrimSpace(entry.Link),
+			Description: entry.Description,
+			PubDate:     strings.TrimSpace(entry.Date),
+			GUID:        strings.TrimSpace(entry.About),
+			Content:     entry.Content,
+			Author:      strings.TrimSpace(entry.Creator),
+			Categories:  entry.Subjects,
+		}
+		if item.Link == "" { //rdf:about is required to be the item's uri
+			item.Link = item.GUID
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return &feed, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import "testing"
+
+func TestParseRDF(t *testing.T) {
+	feed, err := parseRDF(readTestdata(t, "feed.rdf"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	channel := []struct {
+		field     string
+		got, want string
+	}{
+		{"title", feed.Title, "Example Journal"},
+		{"link", feed.Link, "https://example.com/"},
+		{"description", feed.Description, "An RSS 1.0 feed"},
+		{"pubDate", feed.PubDate, "2024-05-06T10:00:00Z"},
+		{"updatePeriod", feed.UpdatePeriod, "daily"},
+		{"updateFrequency", feed.UpdateFrequency, "2"},
+	}
+	for _, f := range channel {
+		if f.got != f.want {
+			t.Errorf("channel %s = %q, want %q", f.field, f.got, f.want)
+		}
+	}
+	if len(feed.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(feed.Items))
+	}
+
+	tests := []struct {
+		name                                    string
+		item                                    feedItem
+		title, link, description, guid, pubDate string
+		author, body                            string
+	}{
+		{
+			name:        "full item",
+			item:        feed.Items[0],
+			title:       "Article one",
+			link:        "https://example.com/articles/1",
+			description: "The first article",
+			guid:        "https://example.com/articles/1",
+			pubDate:     "2024-05-05T08:00:00Z",
+			author:      "Jane Doe",
+			body:        "<p>Article body</p>",
+		},
+		{
+			name:  "link from rdf:about",
+			item:  feed.Items[1],
+			title: "Article two",
+			link:  "https://example.com/articles/2",
+			guid:  "https://example.com/articles/2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := []struct {
+				field     string
+				got, want string
+			}{
+				{"title", tt.item.Title, tt.title},
+				{"link", tt.item.Link, tt.link},
+				{"description", tt.item.Description, tt.description},
+				{"guid", tt.item.GUID, tt.guid},
+				{"pubDate", tt.item.PubDate, tt.pubDate},
+				{"author", tt.item.Author, tt.author},
+				{"content", tt.item.Content, tt.body},
+			}
+			for _, f := range fields {
+				if f.got != f.want {
+					t.Errorf("%s = %q, want %q", f.field, f.got, f.want)
+				}
+			}
+		})
+	}
+
+	if categories := feed.Items[0].Categories; len(categories) != 1 || categories[0] != "science" {
+		t.Errorf("categories = %v, want [science]", categories)
+	}
+}
@@ -1,39 +1,103 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
 	"fmt"
 	"html"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 )
 
-var defaultClient = &http.Client{}
+const fetchTimeout = 30 * time.Second	//Longest a request may take including its body, so one unresponsive server can't hold up a worker or a command
 
-type RSSFeed struct {
+var defaultClient = &http.Client{Timeout: fetchTimeout}
+
+const maxErrorBody = 200	//Bytes of an error response kept in fetch errors
+
+type rssDocument struct {	//RSS 2.0 document as it is decoded, parseRSS maps it into the shared parsedFeed format
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title           string    `xml:"title"`
+		Link            string    `xml:"link"`
+		Description     string    `xml:"description"`
+		PubDate         string    `xml:"pubDate"`
+		LastBuildDate   string    `xml:"lastBuildDate"`
+		TTL             string    `xml:"ttl"`
+		SkipHours       []string  `xml:"skipHours>hour"`
+		SkipDays        []string  `xml:"skipDays>day"`
+		UpdatePeriod    string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+		Item            []rssItem `xml:"item"`
 	} `xml:"channel"`
 }
 
-type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+type rssItem struct {
+	Title        string         `xml:"title"`
+	Link         string         `xml:"link"`
+	Description  string         `xml:"description"`
+	PubDate      string         `xml:"pubDate"`
+	GUID         rssGUID        `xml:"guid"`
+	Content      string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Creator      string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Authors      []rssText      `xml:"author"`
+	Categories   []string       `xml:"category"`
+	CommentsURL  []rssText      `xml:"comments"`
+	Enclosures   []rssEnclosure `xml:"enclosure"`
+	MediaContent []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	Duration     string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	Episode      string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type mediaContent struct {	//Media RSS media:content element
+	URL      string `xml:"url,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Type     string `xml:"type,attr"`
+	Duration string `xml:"duration,attr"`
 }
 
-func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {	//Fetches a rss feed from a given url
+type rssText struct {	//Element text along with its name, so RSS elements can be told apart from same named extension elements
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type rssGUID struct {	//Unique id of an item, a permalink to it unless isPermaLink is "false"
+	Value       string `xml:",chardata"`
+	IsPermaLink string `xml:"isPermaLink,attr"`
+}
+
+type fetchResult struct {	//Outcome of a feed fetch, NotModified is set when a conditional request was answered with 304
+	Feed         *parsedFeed
+	NotModified  bool
+	ETag         string
+	LastModified string
+	StatusCode   int
+	Bytes        int64
+}
+
+func fetchFeed(ctx context.Context, feedURL, etag, lastModified string) (*fetchResult, error) {	//Fetches a feed from a given url, sending cache validators from a previous fetch if given
+	//Once a response has been received, the result is returned even alongside an error, so the status can be logged
 
 	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error forming request: %w", err)
 	}
 	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, */*;q=0.8")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
 
 	res, err := defaultClient.Do(req)
 	if err != nil {
@@ -41,25 +105,148 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {	//Fetche
 	}
 	defer res.Body.Close()
 
+	result := &fetchResult{
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		StatusCode:   res.StatusCode,
+	}
+	if res.StatusCode == http.StatusNotModified {
+		result.NotModified = true
+		return result, nil
+	}
+
 	if res.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(res.Body)
-		defer res.Body.Close()
-		return nil, fmt.Errorf("fetch failed for URL %s: status %d: %s", feedURL, res.StatusCode, string(body))
+		result.Bytes = int64(len(body))
+		if len(body) > maxErrorBody {	//Error pages can be whole html documents, only the start is kept in logs
+			body = append(body[:maxErrorBody], "..."...)
+		}
+		return result, fmt.Errorf("fetch failed for URL %s: status %d: %s", feedURL, res.StatusCode, strings.ToValidUTF8(strings.TrimSpace(string(body)), ""))	//Cutting may split a character, and errors are stored as text
 	}
 
-	var feed RSSFeed
-	if err := xml.NewDecoder(res.Body).Decode(&feed); err != nil {
+	body, err := io.ReadAll(res.Body)
+	result.Bytes = int64(len(body))
+	if err != nil {
+		return result, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	feed, err := parseFeed(body, res.Header.Get("Content-Type"))
+	if err != nil {
+		return result, err
+	}
+	feedUnescape(feed)
+	result.Feed = feed
+	return result, nil
+}
+
+func parseFeed(data []byte, contentType string) (*parsedFeed, error) {	//Sniffs the content type and root element of a feed document, and decodes it with the matching parser
+	if isJSONFeed(data, contentType) {
+		return parseJSONFeed(data)
+	}
+
+	root, err := rootElement(data)
+	if err != nil {
 		return nil, fmt.Errorf("error decoding xml data: %w", err)
 	}
-	feedUnescape(&feed)
+
+	switch root {
+	case "rss":
+		return parseRSS(data)
+	case "feed":
+		return parseAtom(data)
+	case "RDF":
+		return parseRDF(data)
+	default:
+		return nil, fmt.Errorf("unsupported feed format: root element <%s>", root)
+	}
+}
+
+func rootElement(data []byte) (string, error) {	//Returns the local name of the first element in an xml document
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func feedUnescape(feed *parsedFeed) {	//Unescapes certain characters from xml
+	feed.Title = html.UnescapeString(feed.Title)
+	feed.Description = html.UnescapeString(feed.Description)
+	for i, item := range feed.Items {
+		feed.Items[i].Title = html.UnescapeString(item.Title)
+		feed.Items[i].Description = html.UnescapeString(item.Description)
+	}
+}
+
+func parseRSS(data []byte) (*parsedFeed, error) {	//Decodes an RSS 2.0 document and maps it into the shared parsedFeed format, ignoring extension elements such as itunes:author and slash:comments
+	var rss rssDocument
+	if err := xml.Unmarshal(data, &rss); err != nil {
+		return nil, fmt.Errorf("error decoding rss data: %w", err)
+	}
+
+	channel := rss.Channel
+	feed := parsedFeed{
+		Title:           channel.Title,
+		Link:            channel.Link,
+		Description:     channel.Description,
+		PubDate:         channel.PubDate,
+		LastBuildDate:   channel.LastBuildDate,
+		TTL:             channel.TTL,
+		SkipHours:       channel.SkipHours,
+		SkipDays:        channel.SkipDays,
+		UpdatePeriod:    channel.UpdatePeriod,
+		UpdateFrequency: channel.UpdateFrequency,
+	}
+	for _, entry := range channel.Item {
+		item := feedItem{
+			Title:       entry.Title,
+			Link:        entry.Link,
+			Description: entry.Description,
+			PubDate:     entry.PubDate,
+			GUID:        strings.TrimSpace(entry.GUID.Value),
+			Content:     entry.Content,
+			Author:      strings.TrimSpace(entry.Creator),
+			Comments:    unprefixed(entry.CommentsURL),
+			Categories:  entry.Categories,
+			Duration:    entry.Duration,
+			Episode:     entry.Episode,
+		}
+		if item.Author == "" {
+			item.Author = unprefixed(entry.Authors)
+		}
+		if item.Link == "" && entry.GUID.IsPermaLink != "false" {	//A guid is a permalink unless marked otherwise
+			item.Link = item.GUID
+		}
+		for _, enclosure := range entry.Enclosures {
+			item.Enclosures = append(item.Enclosures, feedEnclosure{
+				URL:    enclosure.URL,
+				Length: enclosure.Length,
+				Type:   enclosure.Type,
+			})
+		}
+		for _, media := range entry.MediaContent {	//Media RSS files are kept alongside plain enclosures
+			item.Enclosures = append(item.Enclosures, feedEnclosure{
+				URL:      media.URL,
+				Length:   media.FileSize,
+				Type:     media.Type,
+				Duration: media.Duration,
+			})
+		}
+		feed.Items = append(feed.Items, item)
+	}
 	return &feed, nil
 }
 
-func feedUnescape(feed *RSSFeed) {	//Unescapes certain characters from xml
-	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
-	feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
-	for i, item := range feed.Channel.Item {
-		feed.Channel.Item[i].Title = html.UnescapeString(item.Title)
-		feed.Channel.Item[i].Description = html.UnescapeString(item.Description)
+func unprefixed(elements []rssText) string {	//Returns the text of the first element outside any namespace
+	for _, element := range elements {
+		if element.XMLName.Space == "" {
+			return strings.TrimSpace(element.Value)
+		}
 	}
+	return ""
 }
\ No newline at end of file
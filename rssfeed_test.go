@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("error reading %s: %v", name, err)
+	}
+	return data
+}
+
+func TestParseFeedSniffing(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		wantTitle   string
+		wantErr     bool
+	}{
+		{name: "rss", data: readTestdata(t, "feed.rss"), contentType: "application/rss+xml", wantTitle: "Example Podcast"},
+		{name: "atom", data: readTestdata(t, "feed.atom"), contentType: "application/atom+xml", wantTitle: "Example Blog"},
+		{name: "rdf", data: readTestdata(t, "feed.rdf"), contentType: "application/rdf+xml", wantTitle: "Example Journal"},
+		{name: "json by content type", data: readTestdata(t, "feed.json"), contentType: "application/feed+json; charset=utf-8", wantTitle: "Example Microblog"},
+		{name: "json by body", data: readTestdata(t, "feed.json"), contentType: "text/plain", wantTitle: "Example Microblog"},
+		{name: "xml served as text", data: readTestdata(t, "feed.atom"), contentType: "text/html", wantTitle: "Example Blog"},
+		{name: "unsupported root", data: []byte(`<html><head><title>Not a feed</title></head></html>`), contentType: "text/html", wantErr: true},
+		{name: "json without version", data: []byte(`{"title": "Not a feed"}`), contentType: "application/json", wantErr: true},
+		{name: "empty", data: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := parseFeed(tt.data, tt.contentType)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got feed %q", feed.Title)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if feed.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", feed.Title, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestParseRSS(t *testing.T) {
+	feed, err := parseFeed(readTestdata(t, "feed.rss"), "application/rss+xml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(feed.Items) != 1 {
+		t.Fatalf("got %d items, want 1", len(feed.Items))
+	}
+
+	item := feed.Items[0]
+	tests := []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"title", item.Title, "Episode 1"},
+		{"link", item.Link, "https://example.com/episodes/1"},
+		{"guid", item.GUID, "episode-1"},
+		{"author", item.Author, "Jane Doe"},
+		{"comments", item.Comments, "https://example.com/episodes/1#comments"},
+		{"duration", item.Duration, "30:00"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, tt.got, tt.want)
+		}
+	}
+	if len(item.Categories) != 1 || item.Categories[0] != "examples" {
+		t.Errorf("categories = %v, want [examples]", item.Categories)
+	}
+
+	wantEnclosures := []feedEnclosure{
+		{URL: "https://example.com/episodes/1.mp3", Length: "1048576", Type: "audio/mpeg"},
+		{URL: "https://example.com/episodes/1.mp4", Length: "2097152", Type: "video/mp4", Duration: "1800"},
+	}
+	if len(item.Enclosures) != len(wantEnclosures) {
+		t.Fatalf("got %d enclosures, want %d", len(item.Enclosures), len(wantEnclosures))
+	}
+	for i, want := range wantEnclosures {
+		if item.Enclosures[i] != want {
+			t.Errorf("enclosure %d = %+v, want %+v", i, item.Enclosures[i], want)
+		}
+	}
+}
+
+func TestParseRSSGUIDLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     string
+		wantLink string
+		wantGUID string
+	}{
+		{name: "link given", item: `<link>https://example.com/1</link><guid>https://example.com/one</guid>`, wantLink: "https://example.com/1", wantGUID: "https://example.com/one"},
+		{name: "guid is a permalink by default", item: `<guid> https://example.com/1 </guid>`, wantLink: "https://example.com/1", wantGUID: "https://example.com/1"},
+		{name: "guid marked as a permalink", item: `<guid isPermaLink="true">https://example.com/1</guid>`, wantLink: "https://example.com/1", wantGUID: "https://example.com/1"},
+		{name: "guid not a permalink", item: `<guid isPermaLink="false">episode-1</guid>`, wantGUID: "episode-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := parseRSS([]byte(`<rss version="2.0"><channel><title>t</title><item>` + tt.item + `</item></channel></rss>`))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			item := feed.Items[0]
+			if item.Link != tt.wantLink || item.GUID != tt.wantGUID {
+				t.Errorf("link, guid = %q, %q, want %q, %q", item.Link, item.GUID, tt.wantLink, tt.wantGUID)
+			}
+		})
+	}
+}
+
+func TestFetchFeedConditional(t *testing.T) {
+	const etag = `"v1"`
+	const lastModified = "Mon, 06 May 2024 10:00:00 GMT"
+	feedData := readTestdata(t, "feed.rss")
+
+	var gotETag, gotLastModified string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotETag = r.Header.Get("If-None-Match")
+		gotLastModified = r.Header.Get("If-Modified-Since")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		if gotETag == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write(feedData)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name             string
+		etag             string
+		lastModified     string
+		wantNotModified  bool
+		wantETagSent     string
+		wantModifiedSent string
+	}{
+		{name: "first fetch"},
+		{name: "unchanged", etag: etag, lastModified: lastModified, wantNotModified: true, wantETagSent: etag, wantModifiedSent: lastModified},
+		{name: "stale etag", etag: `"v0"`, wantETagSent: `"v0"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := fetchFeed(context.Background(), server.URL, tt.etag, tt.lastModified)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotETag != tt.wantETagSent || gotLastModified != tt.wantModifiedSent {
+				t.Errorf("sent If-None-Match %q and If-Modified-Since %q, want %q and %q", gotETag, gotLastModified, tt.wantETagSent, tt.wantModifiedSent)
+			}
+			if result.NotModified != tt.wantNotModified {
+				t.Errorf("NotModified = %v, want %v", result.NotModified, tt.wantNotModified)
+			}
+			if tt.wantNotModified && result.Feed != nil {
+				t.Errorf("expected no feed for a 304, got %q", result.Feed.Title)
+			}
+			if !tt.wantNotModified && (result.Feed == nil || result.Feed.Title != "Example Podcast") {
+				t.Errorf("expected the parsed feed, got %+v", result.Feed)
+			}
+			if result.ETag != etag || result.LastModified != lastModified {
+				t.Errorf("validators = %q, %q, want %q, %q", result.ETag, result.LastModified, etag, lastModified)
+			}
+		})
+	}
+}
+
+func TestFetchFeedError(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantMsg string
+	}{
+		{name: "short body", body: "gone fishing", wantMsg: "status 503: gone fishing"},
+		{name: "long body is truncated", body: strings.Repeat("x", 5000), wantMsg: "status 503: " + strings.Repeat("x", maxErrorBody) + "..."},
+		{name: "split character is dropped", body: "a" + strings.Repeat("é", 150), wantMsg: "status 503: a" + strings.Repeat("é", (maxErrorBody-1)/2) + "..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, tt.body, http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			_, err := fetchFeed(context.Background(), server.URL, "", "")
+			if err == nil {
+				t.Fatal("expected an error for a 503 response")
+			}
+			if !strings.HasSuffix(err.Error(), tt.wantMsg) {
+				t.Errorf("error = %q, want it to end with %q", err.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestFetchFeedTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select { //Never answers within the client's timeout
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := defaultClient
+	defaultClient = &http.Client{Timeout: 50 * time.Millisecond}
+	t.Cleanup(func() { defaultClient = client })
+
+	started := time.Now()
+	if _, err := fetchFeed(context.Background(), server.URL, "", ""); err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("fetch took %v, the client timeout was not applied", elapsed)
+	}
+	if client.Timeout != fetchTimeout {
+		t.Errorf("default client timeout = %v, want %v", client.Timeout, fetchTimeout)
+	}
+}
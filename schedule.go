@@ -0,0 +1,104 @@
+package main
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jms-guy/gator/internal/database"
+)
+
+var updatePeriods = map[string]time.Duration{ //Lengths of the sy:updatePeriod values
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+func publishHintsParams(feedID uuid.UUID, feed *parsedFeed) database.UpdateFeedPublishHintsParams { //Parses the polling hints a channel advertises, dropping any invalid values
+	params := database.UpdateFeedPublishHintsParams{
+		ID:              feedID,
+		Ttl:             nullInt32(feed.TTL),
+		UpdateFrequency: nullInt32(feed.UpdateFrequency),
+	}
+
+	for _, hour := range feed.SkipHours {
+		h, err := strconv.Atoi(strings.TrimSpace(hour))
+		if err != nil || h < 0 || h > 24 {
+			continue
+		}
+		params.SkipHours = append(params.SkipHours, int32(h%24)) //Some publishers use 24 for midnight
+	}
+	for _, day := range feed.SkipDays {
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			if strings.EqualFold(strings.TrimSpace(day), weekday.String()) {
+				params.SkipDays = append(params.SkipDays, weekday.String())
+			}
+		}
+	}
+
+	period := strings.ToLower(strings.TrimSpace(feed.UpdatePeriod))
+	if _, ok := updatePeriods[period]; ok {
+		params.UpdatePeriod = nullString(period)
+	}
+	return params
+}
+
+const maxBackoff = 24 * time.Hour //Longest wait between retries of a failing feed
+
+func nextFetchTime(feed database.Feed, defaultInterval time.Duration, now time.Time) time.Time { //Works out when a feed is next due, never polling more often than the publisher asks, and backing off while it fails
+	interval := defaultInterval
+	if feed.FetchInterval.Valid {
+		interval = time.Duration(feed.FetchInterval.Int32) * time.Second
+	}
+	if feed.Ttl.Valid { //ttl is given in minutes
+		interval = max(interval, time.Duration(feed.Ttl.Int32)*time.Minute)
+	}
+	if period, ok := updatePeriods[feed.UpdatePeriod.String]; ok {
+		frequency := int32(1)
+		if feed.UpdateFrequency.Valid && feed.UpdateFrequency.Int32 > 0 {
+			frequency = feed.UpdateFrequency.Int32
+		}
+		interval = max(interval, period/time.Duration(frequency))
+	}
+
+	backoff := interval
+	for i := int32(1); i < feed.ConsecutiveFailures && backoff < maxBackoff; i++ { //Doubles the interval for each failure after the first
+		backoff *= 2
+	}
+	interval = max(interval, min(backoff, maxBackoff))
+
+	next := now.Add(interval).UTC()
+	for i := 0; i < 24*7 && isSkipped(feed, next); i++ { //Skip hours and days are in GMT, move forward an hour at a time until allowed
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+	return next
+}
+
+func isSkipped(feed database.Feed, t time.Time) bool { //Checks a time against a feed's skipHours and skipDays
+	for _, hour := range feed.SkipHours {
+		if int32(t.Hour()) == hour {
+			return true
+		}
+	}
+	for _, day := range feed.SkipDays {
+		if t.Weekday().String() == day {
+			return true
+		}
+	}
+	return false
+}
+
+func nullInt32(str string) sql.NullInt32 { //Converts a possibly empty or invalid number into a nullable database integer
+	number, err := strconv.Atoi(strings.TrimSpace(str))
+	if err != nil || number < 0 {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{
+		Int32: int32(number),
+		Valid: true,
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jms-guy/gator/internal/database"
+)
+
+func TestNextFetchTime(t *testing.T) {
+	now := time.Date(2024, time.May, 6, 10, 30, 0, 0, time.UTC) //A Monday
+	const defaultInterval = 15 * time.Minute
+
+	tests := []struct {
+		name string
+		feed database.Feed
+		want time.Time
+	}{
+		{
+			name: "default interval",
+			want: now.Add(defaultInterval),
+		},
+		{
+			name: "own fetch interval",
+			feed: database.Feed{FetchInterval: sql.NullInt32{Int32: 300, Valid: true}},
+			want: now.Add(5 * time.Minute),
+		},
+		{
+			name: "ttl longer than interval",
+			feed: database.Feed{Ttl: sql.NullInt32{Int32: 60, Valid: true}},
+			want: now.Add(time.Hour),
+		},
+		{
+			name: "ttl shorter than interval",
+			feed: database.Feed{Ttl: sql.NullInt32{Int32: 5, Valid: true}},
+			want: now.Add(defaultInterval),
+		},
+		{
+			name: "update period and frequency",
+			feed: database.Feed{
+				UpdatePeriod:    sql.NullString{String: "daily", Valid: true},
+				UpdateFrequency: sql.NullInt32{Int32: 4, Valid: true},
+			},
+			want: now.Add(6 * time.Hour),
+		},
+		{
+			name: "update period without frequency",
+			feed: database.Feed{UpdatePeriod: sql.NullString{String: "hourly", Valid: true}},
+			want: now.Add(time.Hour),
+		},
+		{
+			name: "first failure keeps the interval",
+			feed: database.Feed{ConsecutiveFailures: 1},
+			want: now.Add(defaultInterval),
+		},
+		{
+			name: "backoff doubles per failure",
+			feed: database.Feed{ConsecutiveFailures: 3},
+			want: now.Add(4 * defaultInterval),
+		},
+		{
+			name: "backoff is capped",
+			feed: database.Feed{ConsecutiveFailures: 30},
+			want: now.Add(maxBackoff),
+		},
+		{
+			name: "skip hours move to the next allowed hour",
+			feed: database.Feed{
+				Ttl:       sql.NullInt32{Int32: 60, Valid: true},
+				SkipHours: []int32{11, 12},
+			},
+			want: time.Date(2024, time.May, 6, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "skip days move to the next allowed day",
+			feed: database.Feed{
+				FetchInterval: sql.NullInt32{Int32: 14 * 60 * 60, Valid: true},
+				SkipDays:      []string{"Tuesday"},
+			},
+			want: time.Date(2024, time.May, 8, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextFetchTime(tt.feed, defaultInterval, now)
+			if !got.Equal(tt.want) {
+				t.Errorf("nextFetchTime = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishHintsParams(t *testing.T) {
+	feed, err := parseFeed(readTestdata(t, "feed.rss"), "application/rss+xml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	feed.SkipHours = append(feed.SkipHours, "25", "soon")
+	feed.SkipDays = append(feed.SkipDays, "someday")
+	feed.UpdatePeriod = " Weekly "
+
+	id := uuid.New()
+	params := publishHintsParams(id, feed)
+	if params.ID != id {
+		t.Errorf("id = %v, want %v", params.ID, id)
+	}
+	if params.Ttl != (sql.NullInt32{Int32: 60, Valid: true}) {
+		t.Errorf("ttl = %+v, want 60", params.Ttl)
+	}
+	if params.UpdateFrequency.Valid {
+		t.Errorf("update frequency = %+v, want null", params.UpdateFrequency)
+	}
+	if len(params.SkipHours) != 2 || params.SkipHours[0] != 1 || params.SkipHours[1] != 0 {
+		t.Errorf("skip hours = %v, want [1 0]", params.SkipHours)
+	}
+	if len(params.SkipDays) != 1 || params.SkipDays[0] != "Sunday" {
+		t.Errorf("skip days = %v, want [Sunday]", params.SkipDays)
+	}
+	if params.UpdatePeriod != (sql.NullString{String: "weekly", Valid: true}) {
+		t.Errorf("update period = %+v, want weekly", params.UpdatePeriod)
+	}
+}
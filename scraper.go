@@ -0,0 +1,519 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jms-guy/gator/internal/database"
+)
+
+const claimLease = 5 * time.Minute	//How long a claimed feed is held, well past fetchTimeout so the lease outlasts the fetch and saving its posts
+
+func scrapeFeeds(s *state, workers int, defaultInterval time.Duration) error {	//Claims batches of feeds that are due, and scrapes them concurrently with a pool of workers
+	jobs := make(chan database.Feed)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for feed := range jobs {
+				var out bytes.Buffer	//Output is buffered per feed so concurrent workers don't interleave lines
+				stats := fetchStats{StartedAt: time.Now().UTC()}
+				scrapeErr := scrapeFeed(s, feed, defaultInterval, &stats, &out)
+				if scrapeErr != nil {
+					fmt.Fprintf(&out, "Error scraping %s: %v\n", feed.Url, scrapeErr)
+					if err := recordFeedFailure(s, feed, scrapeErr, defaultInterval, &out); err != nil {
+						fmt.Fprintln(&out, err)
+					}
+				}
+				if err := logFetchAttempt(s, feed, stats, scrapeErr); err != nil {
+					fmt.Fprintln(&out, err)
+				}
+				fmt.Print(out.String())
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	claimParams := database.ClaimFeedsToFetchParams{
+		LeaseSeconds: int32(claimLease.Seconds()),
+		BatchSize: int32(workers),
+	}
+	for {	//Keeps claiming until no feeds are due, claimed feeds are leased so no other worker or process picks them up until they are rescheduled
+		feeds, err := s.db.ClaimFeedsToFetch(context.Background(), claimParams)
+		if err != nil {
+			return fmt.Errorf("error claiming feeds to fetch: %w", err)
+		}
+		if len(feeds) == 0 {
+			return nil
+		}
+		for _, feed := range feeds {
+			jobs <- feed
+		}
+	}
+}
+
+type fetchStats struct {	//Counts collected while scraping a feed, saved to the fetch log
+	StartedAt  time.Time
+	StatusCode int
+	Bytes      int64
+	ItemsSeen  int
+	Added      int
+	Updated    int
+	Skipped    int
+}
+
+func scrapeFeed(s *state, feedToFetch database.Feed, defaultInterval time.Duration, stats *fetchStats, out io.Writer) error {	//Fetches a single claimed feed, saves its new posts and schedules its next fetch
+	result, fetchErr := fetchFeed(context.Background(), feedToFetch.Url, feedToFetch.Etag.String, feedToFetch.LastModified.String)	//Fetches contents
+	if result != nil {
+		stats.StatusCode = result.StatusCode
+		stats.Bytes = result.Bytes
+	}
+	if fetchErr != nil {
+		return fmt.Errorf("error fetching rss feed of url: %s: %w", feedToFetch.Url, fetchErr)
+	}
+	if result.NotModified {	//Feed unchanged since the last fetch
+		fmt.Fprintln(out, "~~~~~~~~~~~~~~~~~~~~")
+		fmt.Fprintf(out, "Feed: %s\n", feedToFetch.Name)
+		fmt.Fprintln(out, " ~~ Not modified, no new posts ~~")
+		succeededFeed, err := s.db.RecordFeedSuccess(context.Background(), feedToFetch.ID)
+		if err != nil {
+			return fmt.Errorf("error recording feed success: %w", err)
+		}
+		return scheduleFeed(s, succeededFeed, defaultInterval)
+	}
+	feed := result.Feed
+	fmt.Fprintln(out, "~~~~~~~~~~~~~~~~~~~~")
+	fmt.Fprintf(out, "Feed: %s\n", feed.Title)	//Prints contents
+	if len(feed.Items) == 0 {
+		fmt.Fprintf(out, " ~~ No posts in %s ~~\n", feed.Title)
+	}
+	stats.ItemsSeen = len(feed.Items)
+
+	fetchedAt := time.Now().UTC()
+	for _, item := range feed.Items {	//Iterates over posts in feed
+		parsedDate, dateEstimated := itemDate(feed, item, fetchedAt)	//Parses publication date, estimating it if missing or invalid
+		if dateEstimated && strings.TrimSpace(item.PubDate) != "" {
+			fmt.Fprintf(out, " ~~ Could not parse date %q of %s, using an estimate ~~\n", item.PubDate, item.Link)
+		}
+
+		// For a string that might be empty or nil
+		var title sql.NullString
+		if item.Title != "" {
+			title = sql.NullString{
+				String: item.Title,
+				Valid: true,
+			}
+		} else {
+			title = sql.NullString{
+				Valid: false,
+			}
+		}
+		// Same for description
+		var description sql.NullString
+		if item.Description != "" {
+			description = sql.NullString{
+				String: item.Description,
+				Valid: true,
+			}
+		} else {
+			description = sql.NullString{
+				Valid: false,
+			}
+		}
+
+		now := time.Now().UTC()
+		newPost := database.CreatePostParams{	//Create post params
+			ID: uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			Title: title,
+			Url: item.Link,
+			Description: description,
+			PublishedAt: parsedDate,
+			FeedID: feedToFetch.ID,
+			DateEstimated: dateEstimated,
+			Guid: postGUID(item),
+			ContentHash: contentHash(item),
+			Content: nullString(strings.TrimSpace(item.Content)),
+			Author: nullString(strings.TrimSpace(item.Author)),
+			CommentsUrl: nullString(strings.TrimSpace(item.Comments)),
+		}
+		outcome, err := savePost(s, newPost, item)	//Creates or updates post in posts table
+		if err != nil {
+			return fmt.Errorf("error saving post to database: %w", err)
+		}
+
+		postTitle := "[No Title]"
+		if title.Valid {
+			postTitle = title.String
+		}
+		switch outcome {
+		case postAdded:
+			fmt.Fprintf(out, " ~~ %s ~~ Saved to database\n", postTitle)
+			stats.Added++
+		case postUpdated:
+			fmt.Fprintf(out, " ~~ %s ~~ Updated in database\n", postTitle)
+			stats.Updated++
+		default:
+			stats.Skipped++
+		}
+	}
+	fmt.Fprintf(out, "Added %d new posts, updated %d changed posts, skipped %d existing posts\n", stats.Added, stats.Updated, stats.Skipped)
+
+	cacheParams := database.UpdateFeedCacheHeadersParams{	//Saves cache validators only once all posts are stored, so a failed run is fetched in full next time
+		ID: feedToFetch.ID,
+		Etag: nullString(result.ETag),
+		LastModified: nullString(result.LastModified),
+	}
+	if err := s.db.UpdateFeedCacheHeaders(context.Background(), cacheParams); err != nil {
+		return fmt.Errorf("error saving feed cache headers: %w", err)
+	}
+
+	detailsParams := database.UpdateFeedDetailsParams{	//Keeps the site url and description current, missing values don't clear saved ones
+		Link: nullString(strings.TrimSpace(feed.Link)),
+		Description: nullString(strings.TrimSpace(feed.Description)),
+		ID: feedToFetch.ID,
+	}
+	if err := s.db.UpdateFeedDetails(context.Background(), detailsParams); err != nil {
+		return fmt.Errorf("error saving feed details: %w", err)
+	}
+
+	if _, err := s.db.RecordFeedSuccess(context.Background(), feedToFetch.ID); err != nil {
+		return fmt.Errorf("error recording feed success: %w", err)
+	}
+	updatedFeed, err := s.db.UpdateFeedPublishHints(context.Background(), publishHintsParams(feedToFetch.ID, feed))
+	if err != nil {
+		return fmt.Errorf("error saving feed publish hints: %w", err)
+	}
+	return scheduleFeed(s, updatedFeed, defaultInterval)
+}
+
+func recordFeedFailure(s *state, feed database.Feed, scrapeErr error, defaultInterval time.Duration, out io.Writer) error {	//Counts a failed scrape, backing the feed off or disabling it once it reaches the failure limit
+	failureParams := database.RecordFeedFailureParams{
+		LastError: nullString(scrapeErr.Error()),
+		MaxFailures: int32(s.cfg.FeedFailureLimit()),
+		ID: feed.ID,
+	}
+	failedFeed, err := s.db.RecordFeedFailure(context.Background(), failureParams)
+	if err != nil {
+		return fmt.Errorf("error recording feed failure: %w", err)
+	}
+	if failedFeed.Disabled {
+		fmt.Fprintf(out, " ~~ %s disabled after %d consecutive failures ~~\n", failedFeed.Name, failedFeed.ConsecutiveFailures)
+		return nil
+	}
+	return scheduleFeed(s, failedFeed, defaultInterval)
+}
+
+func logFetchAttempt(s *state, feed database.Feed, stats fetchStats, scrapeErr error) error {	//Saves a scrape to the fetch log, whether it succeeded or not
+	attempt := database.CreateFetchAttemptParams{
+		ID: uuid.New(),
+		FeedID: feed.ID,
+		StartedAt: stats.StartedAt,
+		FinishedAt: time.Now().UTC(),
+		ItemsSeen: int32(stats.ItemsSeen),
+		PostsAdded: int32(stats.Added),
+		PostsSkipped: int32(stats.Skipped),
+		PostsUpdated: int32(stats.Updated),
+	}
+	if stats.StatusCode != 0 {	//No status when the request itself failed
+		attempt.HttpStatus = sql.NullInt32{
+			Int32: int32(stats.StatusCode),
+			Valid: true,
+		}
+		attempt.Bytes = sql.NullInt64{
+			Int64: stats.Bytes,
+			Valid: true,
+		}
+	}
+	if scrapeErr != nil {
+		attempt.Error = nullString(scrapeErr.Error())
+	}
+	if err := s.db.CreateFetchAttempt(context.Background(), attempt); err != nil {
+		return fmt.Errorf("error saving fetch attempt: %w", err)
+	}
+	return nil
+}
+
+func scheduleFeed(s *state, feed database.Feed, defaultInterval time.Duration) error {	//Sets the next fetch time of a scraped feed and releases its claim
+	//Sent as a delay so the database adds it to its own now(), next_fetch_at has no time zone and is compared against now() in claims
+	now := time.Now()
+	delay := nextFetchTime(feed, defaultInterval, now).Sub(now)
+	nextParams := database.SetFeedNextFetchParams{
+		DelaySeconds: int32(math.Ceil(delay.Seconds())),
+		ID: feed.ID,
+	}
+	if err := s.db.SetFeedNextFetch(context.Background(), nextParams); err != nil {
+		return fmt.Errorf("error scheduling next fetch: %w", err)
+	}
+	return nil
+}
+
+func nullString(str string) sql.NullString {	//Converts a possibly empty string into a nullable database string
+	return sql.NullString{
+		String: str,
+		Valid: str != "",
+	}
+}
+
+func nullInt64(str string) sql.NullInt64 {	//Converts a possibly empty or invalid number into a nullable database bigint
+	number, err := strconv.ParseInt(strings.TrimSpace(str), 10, 64)
+	if err != nil || number < 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{
+		Int64: number,
+		Valid: true,
+	}
+}
+
+type saveOutcome int	//What saving a fetched item did to the posts table
+
+const (
+	postAdded saveOutcome = iota
+	postUpdated
+	postUnchanged
+)
+
+func savePost(s *state, newPost database.CreatePostParams, item feedItem) (saveOutcome, error) {	//Inserts a new post, or updates an existing one whose content changed, keeping the previous version as a revision
+	existing, err := s.db.GetPostByGuid(context.Background(), database.GetPostByGuidParams{
+		FeedID: newPost.FeedID,
+		Guid: newPost.Guid,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		existing, err = adoptLegacyPost(s, newPost)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := createPost(s, &newPost); err != nil {
+			return 0, err
+		}
+		return postAdded, savePostDetails(s, newPost.ID, item)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if existing.ContentHash == newPost.ContentHash {
+		return postUnchanged, nil
+	}
+	outcome := postUpdated
+	if existing.ContentHash == "" {	//Posts saved before the current hash was tracked, take the fetched version as the baseline without a revision
+		outcome = postUnchanged
+	} else {
+		revision := database.CreatePostRevisionParams{
+			ID: uuid.New(),
+			PostID: existing.ID,
+		}
+		if err := s.db.CreatePostRevision(context.Background(), revision); err != nil {
+			return 0, err
+		}
+	}
+	update := database.UpdatePostParams{
+		ID: existing.ID,
+		UpdatedAt: newPost.UpdatedAt,
+		Title: newPost.Title,
+		Url: newPost.Url,
+		Description: newPost.Description,
+		ContentHash: newPost.ContentHash,
+		Content: newPost.Content,
+		Author: newPost.Author,
+		CommentsUrl: newPost.CommentsUrl,
+	}
+	if _, err := s.db.UpdatePost(context.Background(), update); err != nil {
+		return 0, err
+	}
+	if err := s.db.DeletePostCategories(context.Background(), existing.ID); err != nil {
+		return 0, err
+	}
+	if err := s.db.DeletePostEnclosures(context.Background(), existing.ID); err != nil {
+		return 0, err
+	}
+	return outcome, savePostDetails(s, existing.ID, item)
+}
+
+func adoptLegacyPost(s *state, newPost database.CreatePostParams) (database.Post, error) {	//Finds a post saved before guids were tracked, whose guid was set to its url, and gives it the item's guid
+	legacy, err := s.db.GetLegacyPostByUrl(context.Background(), database.GetLegacyPostByUrlParams{
+		FeedID: newPost.FeedID,
+		Url: newPost.Url,
+	})
+	if err != nil {
+		return database.Post{}, err
+	}
+	return s.db.SetPostGuid(context.Background(), database.SetPostGuidParams{
+		ID: legacy.ID,
+		Guid: newPost.Guid,
+	})
+}
+
+const shortIDLength = 8	//Characters of a post's uuid used as its short id
+const shortIDAttempts = 10
+
+func createPost(s *state, newPost *database.CreatePostParams) error {	//Inserts a new post with the start of its uuid as a permanent short id, drawing a new uuid if another post already has that short id
+	for attempt := 0; attempt < shortIDAttempts; attempt++ {
+		newPost.ShortID = newPost.ID.String()[:shortIDLength]
+		_, err := s.db.CreatePost(context.Background(), *newPost)
+		if err == nil || !strings.Contains(err.Error(), "posts_short_id_key") {
+			return err
+		}
+		newPost.ID = uuid.New()
+	}
+	return fmt.Errorf("error finding a free short id for post %s", newPost.Url)
+}
+
+func savePostDetails(s *state, postID uuid.UUID, item feedItem) error {	//Stores the categories and enclosures of a post
+	for _, category := range postCategories(item) {
+		categoryParams := database.AddPostCategoryParams{
+			PostID: postID,
+			Name: category,
+		}
+		if err := s.db.AddPostCategory(context.Background(), categoryParams); err != nil {
+			return err
+		}
+	}
+
+	for _, enclosure := range postEnclosures(item) {
+		duration := parseMediaDuration(enclosure.Duration)
+		if !duration.Valid {	//itunes:duration describes the item's media when the enclosure has none of its own
+			duration = parseMediaDuration(item.Duration)
+		}
+		enclosureParams := database.AddPostEnclosureParams{
+			PostID: postID,
+			Url: enclosure.URL,
+			Length: nullInt64(enclosure.Length),
+			MimeType: nullString(strings.TrimSpace(enclosure.Type)),
+			Duration: duration,
+			Episode: nullInt32(item.Episode),
+		}
+		if err := s.db.AddPostEnclosure(context.Background(), enclosureParams); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func postEnclosures(item feedItem) []feedEnclosure {	//Returns an item's enclosures with a url, without duplicates
+	var enclosures []feedEnclosure
+	seen := make(map[string]bool)
+	for _, enclosure := range item.Enclosures {
+		enclosure.URL = strings.TrimSpace(enclosure.URL)
+		if enclosure.URL == "" || seen[enclosure.URL] {
+			continue
+		}
+		seen[enclosure.URL] = true
+		enclosures = append(enclosures, enclosure)
+	}
+	return enclosures
+}
+
+func parseMediaDuration(str string) sql.NullInt32 {	//Parses a media duration into seconds, given as seconds or as [HH:]MM:SS like itunes:duration
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return sql.NullInt32{}
+	}
+	var seconds float64
+	for _, part := range strings.Split(str, ":") {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 {
+			return sql.NullInt32{}
+		}
+		seconds = seconds*60 + value
+	}
+	return sql.NullInt32{
+		Int32: int32(seconds),
+		Valid: true,
+	}
+}
+
+func postCategories(item feedItem) []string {	//Returns an item's categories, trimmed and without blanks or duplicates
+	var categories []string
+	seen := make(map[string]bool)
+	for _, category := range item.Categories {
+		category = strings.TrimSpace(category)
+		if category == "" || seen[category] {
+			continue
+		}
+		seen[category] = true
+		categories = append(categories, category)
+	}
+	return categories
+}
+
+func contentHash(item feedItem) string {	//Hashes the parts of an item a publisher may edit, dates are left out as they can be estimated
+	hash := sha256.New()
+	parts := []string{item.Title, item.Link, item.Description, item.Content, item.Author, item.Comments, item.Duration, item.Episode}
+	parts = append(parts, postCategories(item)...)
+	for _, enclosure := range postEnclosures(item) {
+		parts = append(parts, enclosure.URL, enclosure.Length, enclosure.Type, enclosure.Duration)
+	}
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func postGUID(item feedItem) string {	//Identifies a post within its feed by guid, then link, then a hash of its content
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
+	}
+	if link := strings.TrimSpace(item.Link); link != "" {
+		return link
+	}
+	hash := sha256.Sum256([]byte(item.Title + "\n" + item.Description))
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+func itemDate(feed *parsedFeed, item feedItem, fetchedAt time.Time) (time.Time, bool) {	//Returns an item's publication date, falling back to the channel date or the fetch time, flagged as estimated
+	if date, err := parseDate(strings.TrimSpace(item.PubDate)); err == nil {
+		return date, false
+	}
+	for _, channelDate := range []string{feed.PubDate, feed.LastBuildDate} {
+		if date, err := parseDate(strings.TrimSpace(channelDate)); err == nil {
+			return date, true
+		}
+	}
+	return fetchedAt, true
+}
+
+func parseDate(dateStr string) (time.Time, error) {	//Parse a datetime and return it in a parsed format for easier sorting
+	formats := []string{
+		time.RFC1123Z,
+		time.RFC1123,
+		time.RFC3339,
+		time.RFC822,
+		"2006-01-02T15:04:05Z",
+        "2006-01-02T15:04:05-07:00",
+        "2006-01-02T15:04:05+07:00",
+        "2006-01-02 15:04:05",
+        "Mon, 2 Jan 2006 15:04:05 MST",
+        "2 Jan 2006 15:04:05 -0700",
+		"2006-01-02T15:04Z07:00",	//W3CDTF variants used by dc:date
+		"2006-01-02",
+	}
+	var parsedTime time.Time
+	var err error
+
+	for _, format := range formats {
+		parsedTime, err = time.Parse(format, dateStr)
+		if err == nil {
+			return parsedTime, nil
+		}
+	}
+	return time.Time{}, err
+}
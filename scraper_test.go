@@ -0,0 +1,191 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestItemDate(t *testing.T) {
+	fetchedAt := time.Date(2024, time.May, 7, 12, 0, 0, 0, time.UTC)
+	published := time.Date(2024, time.May, 6, 10, 0, 0, 0, time.UTC)
+	channelDate := time.Date(2024, time.May, 5, 8, 0, 0, 0, time.UTC)
+	buildDate := time.Date(2024, time.May, 4, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		itemDate      string
+		channelDate   string
+		buildDate     string
+		want          time.Time
+		wantEstimated bool
+	}{
+		{name: "item date", itemDate: "Mon, 06 May 2024 10:00:00 +0000", channelDate: "Sun, 05 May 2024 08:00:00 +0000", want: published},
+		{name: "item date with spaces", itemDate: " 2024-05-06T10:00:00Z ", want: published},
+		{name: "missing falls back to channel date", channelDate: "Sun, 05 May 2024 08:00:00 +0000", want: channelDate, wantEstimated: true},
+		{name: "invalid falls back to channel date", itemDate: "yesterday", channelDate: "2024-05-05T08:00:00Z", want: channelDate, wantEstimated: true},
+		{name: "falls back to last build date", itemDate: "yesterday", buildDate: "Sat, 04 May 2024 08:00:00 +0000", want: buildDate, wantEstimated: true},
+		{name: "invalid channel date falls back to fetch time", itemDate: "soon", channelDate: "someday", want: fetchedAt, wantEstimated: true},
+		{name: "no dates falls back to fetch time", want: fetchedAt, wantEstimated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var feed parsedFeed
+			feed.PubDate = tt.channelDate
+			feed.LastBuildDate = tt.buildDate
+			got, estimated := itemDate(&feed, feedItem{PubDate: tt.itemDate}, fetchedAt)
+			if !got.Equal(tt.want) || estimated != tt.wantEstimated {
+				t.Errorf("itemDate = %v, %v, want %v, %v", got, estimated, tt.want, tt.wantEstimated)
+			}
+		})
+	}
+}
+
+func TestPostGUID(t *testing.T) {
+	tests := []struct {
+		name string
+		item feedItem
+		want string
+	}{
+		{
+			name: "guid",
+			item: feedItem{GUID: " episode-1 ", Link: "https://example.com/1"},
+			want: "episode-1",
+		},
+		{
+			name: "link when guid is missing",
+			item: feedItem{Link: " https://example.com/1 "},
+			want: "https://example.com/1",
+		},
+		{
+			name: "link when guid is blank",
+			item: feedItem{GUID: "  ", Link: "https://example.com/1"},
+			want: "https://example.com/1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postGUID(tt.item); got != tt.want {
+				t.Errorf("postGUID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostGUIDHashFallback(t *testing.T) {
+	item := feedItem{Title: "No links", Description: "Only text"}
+	guid := postGUID(item)
+	if !strings.HasPrefix(guid, "sha256:") {
+		t.Fatalf("postGUID = %q, want a sha256 hash", guid)
+	}
+	if again := postGUID(item); again != guid {
+		t.Errorf("hash is not stable: %q then %q", guid, again)
+	}
+	if other := postGUID(feedItem{Title: "No links", Description: "Other text"}); other == guid {
+		t.Errorf("different items share the hash %q", guid)
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	base := feedItem{
+		Title:       "Episode 1",
+		Link:        "https://example.com/episodes/1",
+		Description: "The first episode",
+		PubDate:     "Mon, 06 May 2024 10:00:00 +0000",
+		GUID:        "episode-1",
+		Content:     "<p>The first episode</p>",
+		Author:      "Jane Doe",
+		Comments:    "https://example.com/episodes/1#comments",
+		Categories:  []string{"examples", "podcasts"},
+		Enclosures:  []feedEnclosure{{URL: "https://example.com/episodes/1.mp3", Length: "1048576", Type: "audio/mpeg"}},
+		Duration:    "30:00",
+	}
+	baseHash := contentHash(base)
+
+	tests := []struct {
+		name        string
+		edit        func(item *feedItem)
+		wantChanged bool
+	}{
+		{name: "identical item", edit: func(item *feedItem) {}},
+		{name: "date only", edit: func(item *feedItem) { item.PubDate = "Tue, 07 May 2024 10:00:00 +0000" }},
+		{name: "guid only", edit: func(item *feedItem) { item.GUID = "episode-one" }},
+		{name: "title", edit: func(item *feedItem) { item.Title = "Episode 1 (fixed)" }, wantChanged: true},
+		{name: "link", edit: func(item *feedItem) { item.Link = "https://example.com/episodes/one" }, wantChanged: true},
+		{name: "description", edit: func(item *feedItem) { item.Description = "The first episode, edited" }, wantChanged: true},
+		{name: "content only", edit: func(item *feedItem) { item.Content = "<p>The first episode, edited</p>" }, wantChanged: true},
+		{name: "author", edit: func(item *feedItem) { item.Author = "John Roe" }, wantChanged: true},
+		{name: "comments", edit: func(item *feedItem) { item.Comments = "" }, wantChanged: true},
+		{name: "category added", edit: func(item *feedItem) { item.Categories = []string{"examples", "podcasts", "news"} }, wantChanged: true},
+		{name: "duration", edit: func(item *feedItem) { item.Duration = "31:00" }, wantChanged: true},
+		{
+			name: "enclosure only",
+			edit: func(item *feedItem) {
+				item.Enclosures = []feedEnclosure{{URL: "https://example.com/episodes/1-fixed.mp3", Length: "1048576", Type: "audio/mpeg"}}
+			},
+			wantChanged: true,
+		},
+		{
+			name: "enclosure added",
+			edit: func(item *feedItem) {
+				item.Enclosures = append([]feedEnclosure{}, base.Enclosures...)
+				item.Enclosures = append(item.Enclosures, feedEnclosure{URL: "https://example.com/episodes/1.mp4", Type: "video/mp4"})
+			},
+			wantChanged: true,
+		},
+		{
+			name: "duplicate enclosure",
+			edit: func(item *feedItem) {
+				item.Enclosures = append([]feedEnclosure{}, base.Enclosures...)
+				item.Enclosures = append(item.Enclosures, base.Enclosures[0])
+			},
+		},
+		{name: "category padding and duplicates", edit: func(item *feedItem) { item.Categories = []string{" examples", "podcasts ", "examples", ""} }},
+		{
+			name: "text moved between fields",
+			edit: func(item *feedItem) {
+				item.Title = "Episode 1The first episode"
+				item.Description = ""
+			},
+			wantChanged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := base
+			tt.edit(&item)
+			if changed := contentHash(item) != baseHash; changed != tt.wantChanged {
+				t.Errorf("hash changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestParseMediaDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		want  sql.NullInt32
+	}{
+		{"", sql.NullInt32{}},
+		{"95", sql.NullInt32{Int32: 95, Valid: true}},
+		{" 95.7 ", sql.NullInt32{Int32: 95, Valid: true}},
+		{"30:00", sql.NullInt32{Int32: 1800, Valid: true}},
+		{"1:02:03", sql.NullInt32{Int32: 3723, Valid: true}},
+		{"01:00:00", sql.NullInt32{Int32: 3600, Valid: true}},
+		{"half an hour", sql.NullInt32{}},
+		{"10:-5", sql.NullInt32{}},
+		{"1::2", sql.NullInt32{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := parseMediaDuration(tt.input); got != tt.want {
+				t.Errorf("parseMediaDuration(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
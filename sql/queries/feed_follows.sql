@@ -25,7 +25,7 @@ SELECT * FROM feeds
 WHERE url = $1;
 
 -- name: GetFeedFollowsForUser :many
-SELECT feed_follows.*, feeds.name 
+SELECT feed_follows.*, feeds.name, feeds.url, feeds.link
 FROM feed_follows
 INNER JOIN feeds
 ON feed_follows.feed_id = feeds.id
@@ -35,3 +35,9 @@ WHERE feed_follows.user_id = $1;
 DELETE FROM feed_follows
 WHERE user_id = $1
 AND feed_id = $2;
+
+-- name: SetFeedFollowFolder :exec
+UPDATE feed_follows
+SET folder = $3, updated_at = now()
+WHERE user_id = $1
+AND feed_id = $2;
@@ -1,24 +1,94 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, link, description)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8
 )
 RETURNING *;
 
+-- name: GetFeedByID :one
+SELECT * FROM feeds
+WHERE id = $1;
+
 -- name: GetFeeds :many
-SELECT name, url, user_id FROM feeds;
+SELECT name, url, user_id, link, description FROM feeds;
+
+-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET last_fetched_at = now(),
+    claimed_until = now() + sqlc.arg(lease_seconds)::integer * interval '1 second',
+    updated_at = now()
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE NOT disabled
+    AND (next_fetch_at IS NULL OR next_fetch_at <= now())
+    AND (claimed_until IS NULL OR claimed_until <= now())
+    ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
 
--- name: MarkFeedFetched :exec
+-- name: UpdateFeedCacheHeaders :exec
 UPDATE feeds
-SET last_fetched_at = now(), updated_at = now()
+SET etag = $2, last_modified = $3, updated_at = now()
 WHERE id = $1;
 
--- name: GetNextFeedToFetch :one
+-- name: UpdateFeedDetails :exec
+UPDATE feeds
+SET link = COALESCE(sqlc.narg(link), link),
+    description = COALESCE(sqlc.narg(description), description),
+    updated_at = now()
+WHERE id = sqlc.arg(id);
+
+-- name: SetFeedFetchInterval :one
+UPDATE feeds
+SET fetch_interval = sqlc.narg(fetch_interval),
+    next_fetch_at = last_fetched_at + sqlc.narg(fetch_interval) * interval '1 second',
+    updated_at = now()
+WHERE url = sqlc.arg(url)
+RETURNING *;
+
+-- name: UpdateFeedPublishHints :one
+UPDATE feeds
+SET ttl = $2, skip_hours = $3, skip_days = $4, update_period = $5, update_frequency = $6, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetFeedNextFetch :exec
+UPDATE feeds
+SET next_fetch_at = now() + sqlc.arg(delay_seconds)::integer * interval '1 second', claimed_until = NULL, updated_at = now()
+WHERE id = sqlc.arg(id);
+
+-- name: RecordFeedSuccess :one
+UPDATE feeds
+SET consecutive_failures = 0, last_error = NULL, last_success_at = now(), updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: RecordFeedFailure :one
+UPDATE feeds
+SET consecutive_failures = consecutive_failures + 1,
+    last_error = sqlc.arg(last_error),
+    disabled = consecutive_failures + 1 >= sqlc.arg(max_failures)::integer,
+    claimed_until = NULL,
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: GetFailingFeeds :many
 SELECT * FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1;
+WHERE disabled OR consecutive_failures > 0
+ORDER BY disabled DESC, consecutive_failures DESC;
+
+-- name: EnableFeed :one
+UPDATE feeds
+SET disabled = false, consecutive_failures = 0, next_fetch_at = NULL, updated_at = now()
+WHERE url = $1
+RETURNING *;
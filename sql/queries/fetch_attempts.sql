@@ -0,0 +1,32 @@
+-- name: CreateFetchAttempt :exec
+INSERT INTO fetch_attempts (id, feed_id, started_at, finished_at, http_status, bytes, items_seen, posts_added, posts_skipped, error, posts_updated)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11
+);
+
+-- name: GetFetchAttempts :many
+SELECT fetch_attempts.*, feeds.name AS feed_name, feeds.url AS feed_url
+FROM fetch_attempts
+INNER JOIN feeds
+ON fetch_attempts.feed_id = feeds.id
+ORDER BY fetch_attempts.started_at DESC
+LIMIT $1;
+
+-- name: GetFetchAttemptsForFeed :many
+SELECT fetch_attempts.*, feeds.name AS feed_name, feeds.url AS feed_url
+FROM fetch_attempts
+INNER JOIN feeds
+ON fetch_attempts.feed_id = feeds.id
+WHERE feeds.url = $1
+ORDER BY fetch_attempts.started_at DESC
+LIMIT $2;
@@ -0,0 +1,30 @@
+-- name: AddPostEnclosure :exec
+INSERT INTO post_enclosures (post_id, url, length, mime_type, duration, episode)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+ON CONFLICT (post_id, url) DO NOTHING;
+
+-- name: DeletePostEnclosures :exec
+DELETE FROM post_enclosures
+WHERE post_id = $1;
+
+-- name: GetPostEnclosures :many
+SELECT * FROM post_enclosures
+WHERE post_id = $1
+ORDER BY url;
+
+-- name: GetEnclosuresForFeed :many
+SELECT post_enclosures.*, posts.title AS post_title, posts.published_at
+FROM post_enclosures
+INNER JOIN posts
+ON post_enclosures.post_id = posts.id
+INNER JOIN feeds
+ON posts.feed_id = feeds.id
+WHERE feeds.url = $1
+ORDER BY posts.published_at DESC, post_enclosures.url;
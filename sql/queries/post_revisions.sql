@@ -0,0 +1,5 @@
+-- name: CreatePostRevision :exec
+INSERT INTO post_revisions (id, created_at, post_id, title, url, description, content_hash, content)
+SELECT sqlc.arg(id), now(), posts.id, posts.title, posts.url, posts.description, posts.content_hash, posts.content
+FROM posts
+WHERE posts.id = sqlc.arg(post_id);
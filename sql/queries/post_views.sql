@@ -0,0 +1,9 @@
+-- name: MarkPostSeen :exec
+INSERT INTO post_views (user_id, post_id, seen_at)
+VALUES (
+    $1,
+    $2,
+    now()
+)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET seen_at = excluded.seen_at;
@@ -1,5 +1,5 @@
 -- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, date_estimated, guid, content_hash, content, author, comments_url, short_id)
 VALUES (
     $1,
     $2,
@@ -8,14 +8,104 @@ VALUES (
     $5,
     $6,
     $7,
-    $8
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15
 )
 RETURNING *;
 
 -- name: GetPostsForUser :many
-SELECT * FROM posts
+SELECT posts.*, EXISTS (
+    SELECT 1 FROM post_revisions
+    WHERE post_revisions.post_id = posts.id
+    AND post_revisions.created_at > post_views.seen_at  -- never shown posts have nothing to be updated from
+) AS updated_since_seen, feeds.name AS feed_name
+FROM posts
 INNER JOIN feed_follows
 ON posts.feed_id = feed_follows.feed_id
-WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC NULLS LAST
-LIMIT $2;
\ No newline at end of file
+INNER JOIN feeds
+ON posts.feed_id = feeds.id
+LEFT JOIN post_reads
+ON post_reads.post_id = posts.id
+AND post_reads.user_id = feed_follows.user_id
+LEFT JOIN post_views
+ON post_views.post_id = posts.id
+AND post_views.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND (NOT sqlc.arg(unread_only)::boolean OR post_reads.post_id IS NULL)
+AND (COALESCE(cardinality(sqlc.arg(feeds)::text[]), 0) = 0 OR feeds.url = ANY(sqlc.arg(feeds)::text[]) OR feeds.name = ANY(sqlc.arg(feeds)::text[]))
+AND (sqlc.narg(since)::timestamp IS NULL OR posts.published_at >= sqlc.narg(since)::timestamp)
+AND (sqlc.narg(until)::timestamp IS NULL OR posts.published_at < sqlc.narg(until)::timestamp)
+AND (sqlc.narg(keyword)::text IS NULL OR posts.title ILIKE '%' || sqlc.narg(keyword)::text || '%' OR posts.description ILIKE '%' || sqlc.narg(keyword)::text || '%')
+AND (sqlc.narg(before_id)::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM posts AS cursor_post
+    INNER JOIN feeds AS cursor_feed
+    ON cursor_post.feed_id = cursor_feed.id
+    WHERE cursor_post.id = sqlc.narg(before_id)::uuid
+    AND CASE sqlc.arg(sort)::text
+        WHEN 'oldest' THEN (posts.published_at, posts.id) > (cursor_post.published_at, cursor_post.id)
+        WHEN 'created' THEN (posts.created_at, posts.published_at, posts.id) < (cursor_post.created_at, cursor_post.published_at, cursor_post.id)
+        WHEN 'feed' THEN (feeds.name, feeds.id) > (cursor_feed.name, cursor_feed.id)
+            OR ((feeds.name, feeds.id) = (cursor_feed.name, cursor_feed.id) AND (posts.published_at, posts.id) < (cursor_post.published_at, cursor_post.id))
+        ELSE (posts.published_at, posts.id) < (cursor_post.published_at, cursor_post.id)
+    END
+))
+ORDER BY
+    CASE WHEN sqlc.arg(sort)::text = 'feed' THEN feeds.name END,
+    CASE WHEN sqlc.arg(sort)::text = 'feed' THEN feeds.id END,
+    CASE WHEN sqlc.arg(sort)::text = 'created' THEN posts.created_at END DESC,
+    CASE WHEN sqlc.arg(sort)::text = 'oldest' THEN posts.published_at END,
+    CASE WHEN sqlc.arg(sort)::text = 'oldest' THEN posts.id END,
+    posts.published_at DESC,
+    posts.id DESC
+LIMIT sqlc.arg(limit)
+OFFSET sqlc.arg(offset);
+
+-- name: GetPostByGuid :one
+SELECT * FROM posts
+WHERE feed_id = $1
+AND guid = $2;
+
+-- name: GetLegacyPostByUrl :one
+SELECT * FROM posts
+WHERE feed_id = $1
+AND url = $2
+AND guid = url;
+
+-- name: SetPostGuid :one
+UPDATE posts
+SET guid = $2, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdatePost :one
+UPDATE posts
+SET updated_at = $2, title = $3, url = $4, description = $5, content_hash = $6, content = $7, author = $8, comments_url = $9
+WHERE id = $1
+RETURNING *;
+
+-- name: GetPost :one
+SELECT * FROM posts
+WHERE id = $1;
+
+-- name: GetPostByShortID :one
+SELECT * FROM posts
+WHERE short_id = $1;
+
+-- name: SearchPosts :many
+SELECT posts.*, feeds.name AS feed_name, ts_rank(posts.search_vector, websearch_to_tsquery('english', sqlc.arg(query))) AS rank
+FROM posts
+INNER JOIN feeds
+ON posts.feed_id = feeds.id
+WHERE posts.search_vector @@ websearch_to_tsquery('english', sqlc.arg(query))
+AND (sqlc.arg(all_feeds)::boolean OR posts.feed_id IN (
+    SELECT feed_id FROM feed_follows
+    WHERE user_id = sqlc.arg(user_id)
+))
+ORDER BY rank DESC, posts.published_at DESC
+LIMIT sqlc.arg(limit);
@@ -20,4 +20,4 @@ SELECT name FROM users;
 
 -- name: GetUserName :one
 SELECT name FROM users
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD ttl INTEGER,
+ADD skip_hours INTEGER[],
+ADD skip_days TEXT[],
+ADD update_period TEXT,
+ADD update_frequency INTEGER;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN ttl,
+DROP COLUMN skip_hours,
+DROP COLUMN skip_days,
+DROP COLUMN update_period,
+DROP COLUMN update_frequency;
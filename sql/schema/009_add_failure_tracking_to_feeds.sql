@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD consecutive_failures INTEGER NOT NULL DEFAULT 0,
+ADD last_error TEXT,
+ADD last_success_at TIMESTAMP,
+ADD disabled BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN consecutive_failures,
+DROP COLUMN last_error,
+DROP COLUMN last_success_at,
+DROP COLUMN disabled;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE fetch_attempts(
+    id UUID PRIMARY KEY,
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    http_status INTEGER,
+    bytes BIGINT,
+    items_seen INTEGER NOT NULL DEFAULT 0,
+    posts_added INTEGER NOT NULL DEFAULT 0,
+    posts_skipped INTEGER NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+CREATE INDEX fetch_attempts_feed_id_started_at_idx ON fetch_attempts (feed_id, started_at DESC);
+
+-- +goose Down
+DROP TABLE fetch_attempts;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE posts
+ADD date_estimated BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN date_estimated;
@@ -0,0 +1,34 @@
+-- +goose Up
+ALTER TABLE posts
+ADD content_hash TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE post_revisions(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    title TEXT,
+    url TEXT NOT NULL,
+    description TEXT,
+    content_hash TEXT NOT NULL
+);
+
+CREATE TABLE post_views(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    seen_at TIMESTAMP NOT NULL DEFAULT now(),  -- last time browse showed the post to the user
+    PRIMARY KEY (user_id, post_id)
+);
+
+ALTER TABLE fetch_attempts
+ADD posts_updated INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE fetch_attempts
+DROP COLUMN posts_updated;
+
+DROP TABLE post_views;
+
+DROP TABLE post_revisions;
+
+ALTER TABLE posts
+DROP COLUMN content_hash;
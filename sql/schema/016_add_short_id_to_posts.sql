@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE posts
+ADD short_id TEXT;
+
+UPDATE posts SET short_id = left(id::text, 8);  -- new posts take the same start of their uuid when saved
+UPDATE posts SET short_id = id::text  -- the rare existing posts sharing a start keep their full uuid
+WHERE short_id IN (SELECT short_id FROM posts GROUP BY short_id HAVING count(*) > 1);
+
+ALTER TABLE posts
+ALTER COLUMN short_id SET NOT NULL,
+ADD CONSTRAINT posts_short_id_key UNIQUE (short_id);
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN short_id;
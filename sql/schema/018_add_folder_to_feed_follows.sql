@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feed_follows
+ADD COLUMN folder TEXT;  -- folder path from an imported OPML file, nested folders joined with '/'
+
+-- +goose Down
+ALTER TABLE feed_follows
+DROP COLUMN folder;
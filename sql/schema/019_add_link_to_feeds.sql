@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN link TEXT;  -- site url from the channel <link>
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN link;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN content TEXT,
+ADD COLUMN author TEXT,
+ADD COLUMN comments_url TEXT;
+
+ALTER TABLE post_revisions
+ADD COLUMN content TEXT;
+
+CREATE TABLE post_categories(
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    PRIMARY KEY (post_id, name)
+);
+
+UPDATE posts SET content_hash = '';  -- hashes now cover the new fields, the next fetch takes a fresh baseline and fills them in
+
+-- +goose Down
+DROP TABLE post_categories;
+
+ALTER TABLE post_revisions
+DROP COLUMN content;
+
+ALTER TABLE posts
+DROP COLUMN comments_url,
+DROP COLUMN author,
+DROP COLUMN content;
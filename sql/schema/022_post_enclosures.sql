@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE post_enclosures(
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    length BIGINT,  -- size in bytes
+    mime_type TEXT,
+    duration INTEGER,  -- seconds
+    episode INTEGER,
+    PRIMARY KEY (post_id, url)
+);
+
+UPDATE posts SET content_hash = '';  -- hashes now cover enclosures, the next fetch takes a fresh baseline and fills them in
+
+-- +goose Down
+DROP TABLE post_enclosures;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX posts_feed_id_url_idx ON posts (feed_id, url);  -- finds posts saved before guids were tracked
+
+-- +goose Down
+DROP INDEX posts_feed_id_url_idx;
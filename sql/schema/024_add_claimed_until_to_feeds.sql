@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD claimed_until TIMESTAMP;  -- set while a worker scrapes the feed, other claims skip it until then
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN claimed_until;